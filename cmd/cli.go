@@ -17,13 +17,17 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/rkosegi/jdownloader-cli/internal"
 )
 
 func main() {
-	if err := internal.NewRootCommand(os.Stdin, os.Stdout, os.Stderr).Execute(); err != nil {
-		panic(err)
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := internal.Execute(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
 }
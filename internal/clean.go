@@ -0,0 +1,226 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+// cleanOptions selects clean policies, items matching any of enabled policies are removed.
+// Age and file existence conditions restrict finished links and packages only.
+type cleanOptions struct {
+	finished         bool
+	finishedPackages bool
+	failed           bool
+	olderThanDays    int
+	exists           bool
+	packages         []string
+	dryRun           bool
+}
+
+// cleanPlan holds links and packages to be removed, together with human-readable description of each of them.
+type cleanPlan struct {
+	links []int64
+	pkgs  []int64
+	lines []string
+}
+
+func newDownloadCleanCommand(out io.Writer) *cobra.Command {
+	type cleanData struct {
+		opts cleanOptions
+	}
+	var data cleanData
+	c := &cobra.Command{
+		Use:   "clean",
+		Short: "Clean completed downloads",
+		Long: `Clean completed downloads.
+
+Finished links are removed when no policy is given. Finished state is taken from structured finished field,
+not from (localized) status text. Package is finished when all its links are finished, so that disabled links
+which were not downloaded are never removed together with package.
+--older-than-days and --exists restrict finished links and packages, failed links are removed regardless of them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if data.opts.olderThanDays < 0 {
+				return fmt.Errorf("invalid number of days: %d", data.opts.olderThanDays)
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				pkgs, err := dl.Packages()
+				if err != nil {
+					return err
+				}
+				links, err := dl.Links()
+				if err != nil {
+					return err
+				}
+				plan, err := planClean(*pkgs, *links, &data.opts, time.Now(), fileExists)
+				if err != nil {
+					return err
+				}
+				if len(plan.links) == 0 && len(plan.pkgs) == 0 {
+					fmt.Fprintf(out, "Nothing to clean\n")
+					return nil
+				}
+				verb := "will be removed"
+				if data.opts.dryRun {
+					verb = "would be removed"
+				}
+				for _, line := range plan.lines {
+					fmt.Fprintf(out, "%s %s\n", line, verb)
+				}
+				if data.opts.dryRun {
+					return nil
+				}
+				if err = dl.Remove(plan.links, plan.pkgs); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%d links and %d packages cleaned\n", len(plan.links), len(plan.pkgs))
+				return nil
+			})
+		},
+	}
+	c.Flags().BoolVar(&data.opts.finished, "finished", data.opts.finished, "Remove finished links (default when no other policy is given)")
+	c.Flags().BoolVar(&data.opts.finishedPackages, "finished-packages", data.opts.finishedPackages, "Remove packages whose links are all finished")
+	c.Flags().BoolVar(&data.opts.failed, "failed", data.opts.failed, "Remove failed, offline or skipped links")
+	c.Flags().IntVar(&data.opts.olderThanDays, "older-than-days", data.opts.olderThanDays, "Remove only links finished more than given number of days ago")
+	c.Flags().BoolVar(&data.opts.exists, "exists", data.opts.exists, "Remove only links whose file exists on disk (path is resolved locally)")
+	c.Flags().StringArrayVar(&data.opts.packages, "package", data.opts.packages, "Clean only package given by UUID or name. Can be specified multiple times")
+	c.Flags().BoolVar(&data.opts.dryRun, "dry-run", data.opts.dryRun, "Only show what would be removed")
+	return c
+}
+
+// planClean decides which packages and links are removed. Links of removed packages are not listed separately.
+func planClean(pkgs []jdownloader.FilePackage, links []jdownloader.DownloadLink, opts *cleanOptions,
+	now time.Time, exists func(string) bool,
+) (*cleanPlan, error) {
+	scope := map[int64]bool{}
+	if len(opts.packages) > 0 {
+		var err error
+		if scope, err = matchPackages(pkgs, opts.packages); err != nil {
+			return nil, err
+		}
+	}
+	finished := opts.finished || (!opts.finishedPackages && !opts.failed)
+	var cutoff time.Time
+	if opts.olderThanDays > 0 {
+		cutoff = now.AddDate(0, 0, -opts.olderThanDays)
+	}
+	saveTo := make(map[int64]string)
+	children := make(map[int64][]jdownloader.DownloadLink)
+	for _, pkg := range pkgs {
+		if pkg.Uuid != nil {
+			saveTo[*pkg.Uuid] = strVal(pkg.SaveTo)
+		}
+	}
+	for _, link := range links {
+		if link.PackageUuid != nil {
+			children[*link.PackageUuid] = append(children[*link.PackageUuid], link)
+		}
+	}
+	// cleanable tells whether finished link satisfies age and existence conditions
+	cleanable := func(link jdownloader.DownloadLink) bool {
+		if !cutoff.IsZero() && (link.FinishedDate == nil || *link.FinishedDate <= 0 ||
+			!time.UnixMilli(*link.FinishedDate).Before(cutoff)) {
+			return false
+		}
+		if opts.exists {
+			dir := ""
+			if link.PackageUuid != nil {
+				dir = saveTo[*link.PackageUuid]
+			}
+			return len(dir) > 0 && link.Name != nil && exists(filepath.Join(dir, *link.Name))
+		}
+		return true
+	}
+
+	plan := &cleanPlan{links: []int64{}, pkgs: []int64{}}
+	removed := make(map[int64]bool)
+	if opts.finishedPackages {
+		for _, pkg := range pkgs {
+			if pkg.Uuid == nil || (len(scope) > 0 && !scope[*pkg.Uuid]) {
+				continue
+			}
+			pkgLinks := children[*pkg.Uuid]
+			if !isPackageFinished(pkgLinks) {
+				continue
+			}
+			all := true
+			for _, link := range pkgLinks {
+				if !cleanable(link) {
+					all = false
+					break
+				}
+			}
+			if all {
+				removed[*pkg.Uuid] = true
+				plan.pkgs = append(plan.pkgs, *pkg.Uuid)
+				plan.lines = append(plan.lines, fmt.Sprintf("package %s is finished and", strVal(pkg.Name)))
+			}
+		}
+	}
+	for _, link := range links {
+		if link.Uuid == nil {
+			continue
+		}
+		// links without package are cleaned as well, unless cleaning is restricted to selected packages
+		if link.PackageUuid == nil {
+			if len(scope) > 0 {
+				continue
+			}
+		} else if removed[*link.PackageUuid] || (len(scope) > 0 && !scope[*link.PackageUuid]) {
+			continue
+		}
+		switch {
+		case finished && isLinkFinished(link) && cleanable(link):
+			plan.links = append(plan.links, *link.Uuid)
+			plan.lines = append(plan.lines, fmt.Sprintf("%s is finished and", linkLabel(link)))
+		case opts.failed && isLinkFailed(link):
+			plan.links = append(plan.links, *link.Uuid)
+			plan.lines = append(plan.lines, fmt.Sprintf("%s has failed and", linkLabel(link)))
+		}
+	}
+	return plan, nil
+}
+
+// isPackageFinished tells whether package has links and all of them, including disabled ones, are finished.
+func isPackageFinished(links []jdownloader.DownloadLink) bool {
+	for _, link := range links {
+		if !isLinkFinished(link) {
+			return false
+		}
+	}
+	return len(links) > 0
+}
+
+func linkLabel(link jdownloader.DownloadLink) string {
+	if link.Url != nil {
+		return *link.Url
+	}
+	return strVal(link.Name)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanClean(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -10).UnixMilli()
+	recent := now.Add(-time.Hour).UnixMilli()
+	pkgs := []jdownloader.FilePackage{
+		{Uuid: pint64(10), Name: pstr("movies"), SaveTo: pstr("/data/movies")},
+		{Uuid: pint64(20), Name: pstr("music"), SaveTo: pstr("/data/music")},
+	}
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Name: pstr("a.mkv"), Finished: pbool(true), FinishedDate: &old,
+			Status: pstr("Fertig")},
+		{Uuid: pint64(2), PackageUuid: pint64(10), Name: pstr("b.mkv"), Enabled: pbool(false)},
+		{Uuid: pint64(3), PackageUuid: pint64(20), Name: pstr("c.mp3"), Finished: pbool(true), FinishedDate: &recent},
+		{Uuid: pint64(4), PackageUuid: pint64(20), Name: pstr("d.mp3"), StatusIconKey: pstr("false")},
+		{Uuid: pint64(5), Name: pstr("orphan.zip"), Finished: pbool(true)},
+	}
+	none := func(string) bool { return false }
+
+	plan, err := planClean(pkgs, links, &cleanOptions{}, now, none)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3, 5}, plan.links)
+	assert.Empty(t, plan.pkgs)
+
+	plan, err = planClean(pkgs, links, &cleanOptions{finishedPackages: true, failed: true}, now, none)
+	assert.NoError(t, err)
+	assert.Empty(t, plan.pkgs)
+	assert.Equal(t, []int64{4}, plan.links)
+
+	links[1].Finished = pbool(true)
+	plan, err = planClean(pkgs, links, &cleanOptions{finishedPackages: true, failed: true}, now, none)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{10}, plan.pkgs)
+	assert.Equal(t, []int64{4}, plan.links)
+	links[1].Finished = nil
+
+	plan, err = planClean(pkgs, links, &cleanOptions{olderThanDays: 7}, now, none)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, plan.links)
+
+	plan, err = planClean(pkgs, links, &cleanOptions{exists: true}, now, func(path string) bool {
+		return path == "/data/music/c.mp3"
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, plan.links)
+
+	plan, err = planClean(pkgs, links, &cleanOptions{packages: []string{"movies"}}, now, none)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, plan.links)
+
+	_, err = planClean(pkgs, links, &cleanOptions{packages: []string{"books"}}, now, none)
+	assert.Error(t, err)
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	xlog "github.com/rkosegi/slog-config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const defaultApiTimeout = 30 * time.Second
+
+type cliContextKey struct{}
+
+// cliContext holds global flags of single invocation together with state resolved from them.
+// It is shared by all subcommands, client, device and logger are resolved lazily on first use.
+type cliContext struct {
+	ctx       context.Context
+	in        io.Reader
+	out       io.Writer
+	errOut    io.Writer
+	debug     bool
+	device    string
+	context   string
+	timeout   time.Duration
+	retries   int
+	output    outputFormat
+	logFormat xlog.Format
+	// backoff and maxBackoff can only be set in config file
+	backoff    time.Duration
+	maxBackoff time.Duration
+	flags      *pflag.FlagSet
+
+	logger *slog.Logger
+	client jdownloader.JdClient
+	cfg    *contextData
+	dev    jdownloader.Device
+	// started is set once flags and arguments were accepted and command is about to run
+	started bool
+	// cachedSession is name of context whose session cache holds session of client
+	cachedSession string
+}
+
+func newCliContext(in io.Reader, out, errOut io.Writer) *cliContext {
+	return &cliContext{
+		ctx:        context.Background(),
+		in:         in,
+		out:        out,
+		errOut:     errOut,
+		timeout:    defaultApiTimeout,
+		retries:    defaultApiRetries,
+		backoff:    defaultApiBackoff,
+		maxBackoff: defaultApiMaxBackoff,
+		logFormat:  xlog.MustNew("info", xlog.LogFormatLogFmt).Format,
+	}
+}
+
+// addFlags registers global flags, they are meant to be persistent flags of root command.
+func (c *cliContext) addFlags(fs *pflag.FlagSet) {
+	c.flags = fs
+	fs.BoolVar(&c.debug, "debug", c.debug, "Enable debug logging")
+	fs.StringVar(&c.device, "device", c.device, "Device name to use for this operation")
+	fs.StringVar(&c.context, "context", c.context, "Name of config context to use (defaults to current context)")
+	fs.DurationVar(&c.timeout, "timeout", c.timeout, "Timeout of single API call")
+	fs.IntVar(&c.retries, "retries", c.retries, "How many times to retry API call which failed with transient error")
+	addOutputFlag(fs, &c.output)
+	fs.Var(&c.logFormat, "log-format", "Log format. One of: logfmt|json")
+}
+
+func (c *cliContext) validate() error {
+	if c.timeout <= 0 {
+		return errors.New("timeout must be positive duration")
+	}
+	if c.retries < 0 {
+		return errors.New("retries must not be negative")
+	}
+	if c.backoff <= 0 || c.maxBackoff < c.backoff {
+		return errors.New("backoff must be positive duration not greater than max-backoff")
+	}
+	return nil
+}
+
+// applyConfig takes API settings from config file, unless they were given by flags.
+func (c *cliContext) applyConfig(cfg *apiConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Timeout != nil && !c.flagChanged("timeout") {
+		c.timeout = *cfg.Timeout
+	}
+	if cfg.Retries != nil && !c.flagChanged("retries") {
+		c.retries = *cfg.Retries
+	}
+	if cfg.Backoff != nil {
+		c.backoff = *cfg.Backoff
+	}
+	if cfg.MaxBackoff != nil {
+		c.maxBackoff = *cfg.MaxBackoff
+	}
+	return c.validate()
+}
+
+func (c *cliContext) flagChanged(name string) bool {
+	return c.flags != nil && c.flags.Changed(name)
+}
+
+// attach makes cliContext available to command which is about to run.
+// API calls are bound to context of command from now on, so that they are aborted when it is cancelled.
+func (c *cliContext) attach(cmd *cobra.Command) {
+	if ctx := cmd.Context(); ctx != nil {
+		c.ctx = ctx
+	}
+	cmd.SetContext(context.WithValue(c.ctx, cliContextKey{}, c))
+}
+
+// cliContextOf returns cliContext of invocation which runs given command.
+// Command executed outside of root command gets fresh one with default flags.
+func cliContextOf(cmd *cobra.Command) *cliContext {
+	if ctx := cmd.Context(); ctx != nil {
+		if c, ok := ctx.Value(cliContextKey{}).(*cliContext); ok {
+			return c
+		}
+	}
+	c := newCliContext(cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+	c.attach(cmd)
+	return c
+}
+
+func (c *cliContext) getLogger() *slog.Logger {
+	if c.logger == nil {
+		level := "info"
+		if c.debug {
+			level = "debug"
+		}
+		c.logger = xlog.MustNew(level, xlog.LogFormat(c.logFormat.String())).Logger()
+	}
+	return c.logger
+}
+
+func (c *cliContext) printer() printer {
+	return printer{out: c.out, format: c.output}
+}
+
+func (c *cliContext) retryPolicy() *retryPolicy {
+	return &retryPolicy{
+		ctx:        c.ctx,
+		retries:    c.retries,
+		backoff:    c.backoff,
+		maxBackoff: c.maxBackoff,
+		logger:     c.getLogger(),
+		sleep:      sleepCtx,
+	}
+}
+
+// newClient returns client which retries failed API calls according to retryPolicy.
+// When server rejects session of client, client logs in again using credentials of ctx.
+func (c *cliContext) newClient(ctx *contextData, opts ...jdownloader.ClientOption) jdownloader.JdClient {
+	opts = append([]jdownloader.ClientOption{
+		jdownloader.ClientOptionTimeout(c.timeout),
+		jdownloader.ClientOptionAppKey("jdcli"),
+	}, opts...)
+	client := jdownloader.NewClient(*ctx.Mail, *ctx.Password, c.getLogger(), opts...)
+	p := c.retryPolicy()
+	var mu sync.Mutex
+	p.relogin = func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := callCtx(ctx, func() (struct{}, error) {
+			return struct{}{}, client.Connect()
+		})
+		return err
+	}
+	return &retryingClient{JdClient: client, p: p}
+}
+
+// getClient returns connected client of selected config context.
+func (c *cliContext) getClient() (jdownloader.JdClient, *contextData, error) {
+	if c.client == nil {
+		client, cfg, err := connectClient(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.client, c.cfg = client, cfg
+	}
+	return c.client, c.cfg, nil
+}
+
+// getDevice returns device picked by resolveDeviceName.
+func (c *cliContext) getDevice() (jdownloader.Device, error) {
+	if c.dev == nil {
+		client, cfg, err := c.getClient()
+		if err != nil {
+			return nil, err
+		}
+		name, err := resolveDeviceName(client, c.device, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if c.dev, err = client.Device(name); err != nil {
+			if classifyError(err) == exitCodeError {
+				err = withExitCode(exitCodeNoDevice, fmt.Errorf("unable to use device '%s': %w", name, err))
+			}
+			return nil, err
+		}
+	}
+	return c.dev, nil
+}
+
+// close disconnects client, unless its session is cached for subsequent invocations.
+// Cached session is stored again, so that its expiration is extended and tokens renewed meanwhile are kept.
+// Interrupted invocation disconnects in any case and drops cached session, so that no session is left behind.
+func (c *cliContext) close(interrupted bool) {
+	if c.client == nil {
+		return
+	}
+	if len(c.cachedSession) > 0 && !interrupted {
+		if err := storeSession(c.cachedSession, c.cfg, c.client.Session()); err != nil {
+			c.getLogger().Warn("unable to cache session", "error", err)
+		}
+		return
+	}
+	clientCloser(c.client, c.errOut)
+	if len(c.cachedSession) == 0 {
+		return
+	}
+	cache, err := readSessionCache()
+	if err == nil {
+		delete(cache, c.cachedSession)
+		err = writeSessionCache(cache)
+	}
+	if err != nil {
+		c.getLogger().Warn("unable to drop cached session", "error", err)
+	}
+}
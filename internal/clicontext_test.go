@@ -0,0 +1,116 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobalFlags(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	var out bytes.Buffer
+	root := NewRootCommand(strings.NewReader(""), &out, &out)
+	var cc *cliContext
+	probe := &cobra.Command{
+		Use: "probe",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc = cliContextOf(cmd)
+			return nil
+		},
+	}
+	root.AddCommand(probe)
+	root.SetArgs([]string{"probe", "--debug", "--device", "nas", "--context", "work", "--timeout", "5s",
+		"-o", "json", "--log-format", "json"})
+	assert.NoError(t, root.Execute())
+	assert.True(t, cc.debug)
+	assert.Equal(t, "nas", cc.device)
+	assert.Equal(t, "work", cc.context)
+	assert.Equal(t, 5*time.Second, cc.timeout)
+	assert.Equal(t, outputFormat(outputJson), cc.printer().format)
+	assert.Equal(t, "json", cc.logFormat.String())
+	assert.NotNil(t, cc.getLogger())
+
+	root.SetArgs([]string{"--debug", "version"})
+	assert.NoError(t, root.Execute())
+
+	root.SetArgs([]string{"version", "--timeout", "0s"})
+	assert.Error(t, root.Execute())
+}
+
+func TestCliContextOfDefault(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cc := cliContextOf(cmd)
+	assert.Equal(t, defaultApiTimeout, cc.timeout)
+	assert.Same(t, cc, cliContextOf(cmd))
+	assert.Equal(t, &out, cc.printer().out)
+}
+
+type disconnectCounter struct {
+	jdownloader.JdClient
+	disconnects int
+}
+
+func (d *disconnectCounter) Disconnect() error {
+	d.disconnects++
+	return nil
+}
+
+func (d *disconnectCounter) Session() *jdownloader.Session {
+	return &jdownloader.Session{}
+}
+
+func TestCliContextClose(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	mail, pass := "me@example.com", "secret"
+	ctx := &contextData{Mail: &mail, Password: &pass}
+	assert.NoError(t, storeSession("default", ctx, &jdownloader.Session{}))
+	cache, err := readSessionCache()
+	assert.NoError(t, err)
+	cache["default"].Expires = time.Now().Add(time.Minute)
+	assert.NoError(t, writeSessionCache(cache))
+
+	client := &disconnectCounter{}
+	cc := newCliContext(nil, io.Discard, io.Discard)
+	cc.close(false)
+	cc.client, cc.cfg, cc.cachedSession = client, ctx, "default"
+	cc.close(false)
+	assert.Equal(t, 0, client.disconnects)
+	cache, err = readSessionCache()
+	assert.NoError(t, err)
+	assert.Greater(t, time.Until(cache["default"].Expires), sessionCacheTTL-time.Minute)
+
+	cc.close(true)
+	assert.Equal(t, 1, client.disconnects)
+	cache, err = readSessionCache()
+	assert.NoError(t, err)
+	assert.Empty(t, cache)
+
+	cc.cachedSession = ""
+	cc.close(false)
+	assert.Equal(t, 2, client.disconnects)
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const (
+	availabilityOnline  = "ONLINE"
+	availabilityOffline = "OFFLINE"
+)
+
+var (
+	clPkgCols = []column[jdownloader.CrawledPackage]{
+		{name: "ID", value: func(p jdownloader.CrawledPackage) string { return idVal(p.Uuid) },
+			key: func(p jdownloader.CrawledPackage) any { return intVal(p.Uuid) }},
+		{name: "Name", value: func(p jdownloader.CrawledPackage) string { return strVal(p.Name) }},
+		{name: "Links", value: func(p jdownloader.CrawledPackage) string { return countVal(p.ChildCount) },
+			key: func(p jdownloader.CrawledPackage) any { return countKey(p.ChildCount) }},
+		{name: "Online", value: func(p jdownloader.CrawledPackage) string { return countVal(p.OnlineCount) },
+			key: func(p jdownloader.CrawledPackage) any { return countKey(p.OnlineCount) }},
+		{name: "Offline", value: func(p jdownloader.CrawledPackage) string { return countVal(p.OfflineCount) },
+			key: func(p jdownloader.CrawledPackage) any { return countKey(p.OfflineCount) }},
+		{name: "Unknown", wide: true, value: func(p jdownloader.CrawledPackage) string { return countVal(p.UnknownCount) },
+			key: func(p jdownloader.CrawledPackage) any { return countKey(p.UnknownCount) }},
+		{name: "Hosts", wide: true, value: func(p jdownloader.CrawledPackage) string {
+			if p.Hosts == nil {
+				return ""
+			}
+			return strings.Join(*p.Hosts, ", ")
+		}},
+		{name: "Save to", wide: true, value: func(p jdownloader.CrawledPackage) string { return strVal(p.SaveTo) }},
+		{name: "Total size", value: func(p jdownloader.CrawledPackage) string { return formatSize(p.BytesTotal) },
+			key: func(p jdownloader.CrawledPackage) any { return intVal(p.BytesTotal) }},
+	}
+	clPkgExtraCols = []column[jdownloader.CrawledPackage]{
+		{name: "Enabled", value: func(p jdownloader.CrawledPackage) string { return boolVal(p.Enabled) }},
+		{name: "Priority", value: func(p jdownloader.CrawledPackage) string { return strVal(p.Priority) }},
+		{name: "Comment", value: func(p jdownloader.CrawledPackage) string { return strVal(p.Comment) }},
+	}
+)
+
+func countVal(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprint(*n)
+}
+
+func countKey(n *int) any {
+	if n == nil {
+		return int64(0)
+	}
+	return int64(*n)
+}
+
+func newListCollectorPackagesCommand(out io.Writer, use string) *cobra.Command {
+	type listData struct {
+		list listOptions
+	}
+	var data listData
+	c := &cobra.Command{
+		Use:   use,
+		Short: "List packages in LinkGrabber",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				pkgs, err := dev.LinkGrabber().Packages()
+				if err != nil {
+					return err
+				}
+				p := cliContextOf(cmd).printer()
+				if len(*pkgs) == 0 && p.isTable() {
+					fmt.Fprintf(out, "No packages\n")
+					return nil
+				}
+				return printListing(p, data.list, *pkgs, clPkgCols, clPkgExtraCols)
+			})
+		},
+	}
+	addListFlags(c.Flags(), &data.list)
+	return c
+}
+
+func newConfirmLinksCommand(out io.Writer) *cobra.Command {
+	type confirmData struct {
+		name      string
+		links     []int64
+		autoStart bool
+	}
+	var data confirmData
+	c := &cobra.Command{
+		Use:   "confirm [PACKAGE...]",
+		Short: "Move links from LinkGrabber to download list",
+		Long: `Move links from LinkGrabber to download list.
+Packages are selected by UUID or name arguments and by --name expression, links by --link.
+All packages are moved when no selector is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				pkgIds := make([]int64, 0)
+				if len(args) > 0 || len(data.name) > 0 || len(data.links) == 0 {
+					refs, err := collectorPackages.refs(dev)
+					if err != nil {
+						return err
+					}
+					if pkgIds, err = matchPackageRefs(refs, args, data.name); err != nil {
+						return err
+					}
+					if len(args) == 0 && len(data.name) == 0 {
+						for _, ref := range refs {
+							pkgIds = append(pkgIds, ref.uuid)
+						}
+					}
+				}
+				if len(pkgIds) == 0 && len(data.links) == 0 {
+					fmt.Fprintf(out, "Nothing to confirm\n")
+					return nil
+				}
+				return confirmLinks(out, dev, data.links, pkgIds, data.autoStart)
+			})
+		},
+	}
+	c.Flags().StringVar(&data.name, "name", data.name, "Select packages with name matching regular expression")
+	c.Flags().Int64SliceVar(&data.links, "link", data.links, "Link UUID to confirm. Can be specified multiple times")
+	c.Flags().BoolVar(&data.autoStart, "auto-start", data.autoStart, "Start downloads after links are confirmed")
+	return c
+}
+
+// confirmLinks moves links and packages to download list, optionally starting downloader afterwards.
+func confirmLinks(out io.Writer, dev jdownloader.Device, linkIds, pkgIds []int64, autoStart bool) error {
+	if err := dev.LinkGrabber().MoveToDownloadlist(linkIds, pkgIds); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d links and %d packages moved to download list\n", len(linkIds), len(pkgIds))
+	if autoStart {
+		if _, err := dev.Downloader().Start(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Downloads started\n")
+	}
+	return nil
+}
+
+func newRmLinksCommand(out io.Writer) *cobra.Command {
+	type rmData struct {
+		sel removeSelector
+	}
+	var data rmData
+	c := &cobra.Command{
+		Use:   "rm [UUID...|-]",
+		Short: "Remove links from LinkGrabber selected by identifiers or filters",
+		Long: `Remove links from LinkGrabber selected by identifiers or filters, all given conditions must match.
+Identifiers can be given as arguments or read from standard input when argument is '-'.
+Matching links are shown and removal has to be confirmed, unless --yes is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdin, err := readIdArgs(args, cmd.InOrStdin(), &data.sel.ids)
+			if err != nil {
+				return err
+			}
+			if data.sel.empty() {
+				return withExitCode(exitCodeUsage, errors.New("no link identifier(s) or selector was specified (use --id id1 --id id2 ..., --filter, ...)"))
+			}
+			if stdin && !data.sel.yes && !data.sel.dryRun {
+				return errors.New("--yes is required when identifiers are read from standard input")
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				lg := dev.LinkGrabber()
+				links, err := lg.Links()
+				if err != nil {
+					return err
+				}
+				refs, err := collectorPackages.refs(dev)
+				if err != nil {
+					return err
+				}
+				matched, err := selectCrawledLinks(*links, refs, &data.sel)
+				if err != nil {
+					return err
+				}
+				return removeCrawledLinks(cmd.InOrStdin(), out, lg, &data.sel, matched)
+			})
+		},
+	}
+	addRemoveSelectorFlags(c.Flags(), &data.sel, "Link identifier. Can be specified multiple times")
+	c.Flags().BoolVar(&data.sel.failed, "offline", data.sel.failed, "Remove offline links")
+	return c
+}
+
+func newClearLinksCommand(out io.Writer) *cobra.Command {
+	type clearData struct {
+		yes bool
+	}
+	var data clearData
+	c := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all links from LinkGrabber",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				if !data.yes {
+					ok, err := confirm(cmd.InOrStdin(), out, "Remove all links from LinkGrabber?")
+					if err != nil || !ok {
+						return err
+					}
+				}
+				if err := dev.LinkGrabber().ClearList(); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "LinkGrabber cleared\n")
+				return nil
+			})
+		},
+	}
+	c.Flags().BoolVarP(&data.yes, "yes", "y", data.yes, "Don't ask for confirmation")
+	return c
+}
+
+func newCleanupLinksCommand(out io.Writer) *cobra.Command {
+	type cleanupData struct {
+		offline    bool
+		duplicates bool
+		unknown    bool
+		sel        removeSelector
+	}
+	var data cleanupData
+	c := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Remove offline, duplicate or unchecked links from LinkGrabber",
+		Long: `Remove offline, duplicate or unchecked links from LinkGrabber.
+Duplicates are links with same URL, first occurrence of each URL is kept.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !data.offline && !data.duplicates && !data.unknown {
+				return errors.New("no cleanup policy specified (use --offline, --duplicates and/or --unknown)")
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				lg := dev.LinkGrabber()
+				links, err := lg.Links()
+				if err != nil {
+					return err
+				}
+				matched := cleanupCrawledLinks(*links, data.offline, data.duplicates, data.unknown)
+				return removeCrawledLinks(cmd.InOrStdin(), out, lg, &data.sel, matched)
+			})
+		},
+	}
+	c.Flags().BoolVar(&data.offline, "offline", data.offline, "Remove offline links")
+	c.Flags().BoolVar(&data.duplicates, "duplicates", data.duplicates, "Remove links with duplicate URL")
+	c.Flags().BoolVar(&data.unknown, "unknown", data.unknown, "Remove links with unknown availability")
+	c.Flags().BoolVarP(&data.sel.yes, "yes", "y", data.sel.yes, "Don't ask for confirmation")
+	c.Flags().BoolVar(&data.sel.dryRun, "dry-run", data.sel.dryRun, "Only show what would be removed")
+	return c
+}
+
+func removeCrawledLinks(in io.Reader, out io.Writer, lg jdownloader.LinkGrabber, sel *removeSelector, links []jdownloader.CrawledLink) error {
+	ok, err := confirmRemoval(in, out, sel, links, clCols, "links")
+	if err != nil || !ok {
+		return err
+	}
+	ids := make([]int64, 0, len(links))
+	for _, link := range links {
+		ids = append(ids, *link.Uuid)
+	}
+	if err = lg.RemoveLinks(ids, []int64{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d links removed\n", len(ids))
+	return nil
+}
+
+// selectCrawledLinks returns collected links matching all conditions of selector, failed condition matches offline links.
+func selectCrawledLinks(links []jdownloader.CrawledLink, refs []packageRef, sel *removeSelector) ([]jdownloader.CrawledLink, error) {
+	nameRe, err := compileNameRe(sel.name)
+	if err != nil {
+		return nil, err
+	}
+	var pkgIds []int64
+	if len(sel.packages) > 0 {
+		if pkgIds, err = matchPackageRefs(refs, sel.packages, ""); err != nil {
+			return nil, err
+		}
+	}
+	if links, err = filterList(links, sel.filter); err != nil {
+		return nil, err
+	}
+	res := make([]jdownloader.CrawledLink, 0)
+	for _, link := range links {
+		switch {
+		case link.Uuid == nil:
+		case len(sel.ids) > 0 && !slices.Contains(sel.ids, *link.Uuid):
+		case len(sel.status) > 0 && !strings.EqualFold(strVal(link.Status), sel.status):
+		case !matchHost(strVal(link.Host), sel.host):
+		case nameRe != nil && !nameRe.MatchString(strVal(link.Name)):
+		case pkgIds != nil && (link.PackageUuid == nil || !slices.Contains(pkgIds, *link.PackageUuid)):
+		case sel.failed && !strings.EqualFold(strVal(link.Availability), availabilityOffline):
+		default:
+			res = append(res, link)
+		}
+	}
+	return res, nil
+}
+
+// cleanupCrawledLinks returns collected links which are offline, duplicate or have unknown availability.
+func cleanupCrawledLinks(links []jdownloader.CrawledLink, offline, duplicates, unknown bool) []jdownloader.CrawledLink {
+	res := make([]jdownloader.CrawledLink, 0)
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if link.Uuid == nil {
+			continue
+		}
+		availability := strings.ToUpper(strVal(link.Availability))
+		dup := link.Url != nil && seen[*link.Url]
+		if link.Url != nil {
+			seen[*link.Url] = true
+		}
+		switch {
+		case offline && availability == availabilityOffline,
+			unknown && availability != availabilityOnline && availability != availabilityOffline,
+			duplicates && dup:
+			res = append(res, link)
+		}
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func crawledLinks() []jdownloader.CrawledLink {
+	return []jdownloader.CrawledLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Url: pstr("https://a.com/1"), Availability: pstr("ONLINE"), Host: pstr("a.com")},
+		{Uuid: pint64(2), PackageUuid: pint64(10), Url: pstr("https://a.com/1"), Availability: pstr("ONLINE"), Host: pstr("a.com")},
+		{Uuid: pint64(3), PackageUuid: pint64(20), Url: pstr("https://b.com/2"), Availability: pstr("OFFLINE"), Host: pstr("b.com")},
+		{Uuid: pint64(4), PackageUuid: pint64(20), Url: pstr("https://b.com/3"), Availability: pstr("UNKNOWN"), Host: pstr("b.com")},
+	}
+}
+
+func uuids(links []jdownloader.CrawledLink) []int64 {
+	res := make([]int64, len(links))
+	for i, l := range links {
+		res[i] = *l.Uuid
+	}
+	return res
+}
+
+func TestCleanupCrawledLinks(t *testing.T) {
+	links := crawledLinks()
+	assert.Equal(t, []int64{3}, uuids(cleanupCrawledLinks(links, true, false, false)))
+	assert.Equal(t, []int64{2}, uuids(cleanupCrawledLinks(links, false, true, false)))
+	assert.Equal(t, []int64{4}, uuids(cleanupCrawledLinks(links, false, false, true)))
+	assert.Equal(t, []int64{2, 3, 4}, uuids(cleanupCrawledLinks(links, true, true, true)))
+}
+
+func TestSelectCrawledLinks(t *testing.T) {
+	refs := []packageRef{{uuid: 10, name: "first"}, {uuid: 20, name: "second"}}
+	res, err := selectCrawledLinks(crawledLinks(), refs, &removeSelector{packages: []string{"second"}, failed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, uuids(res))
+	res, err = selectCrawledLinks(crawledLinks(), refs, &removeSelector{host: "a.com", ids: []int64{2, 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, uuids(res))
+	_, err = selectCrawledLinks(crawledLinks(), refs, &removeSelector{packages: []string{"third"}})
+	assert.Error(t, err)
+}
@@ -19,55 +19,110 @@ package internal
 import (
 	"errors"
 	"fmt"
-	"log/slog"
 	"os"
-	"time"
 
-	"github.com/rkosegi/jdownloader-go/jdownloader"
-	xlog "github.com/rkosegi/slog-config"
 	"gopkg.in/yaml.v3"
 )
 
+const defaultContextName = "default"
+
+type contextData struct {
+	Mail     *string `yaml:"mail,omitempty"`
+	Password *string `yaml:"password,omitempty"`
+	Device   *string `yaml:"device,omitempty"`
+}
+
+// configData holds named contexts. Top-level credentials of single-account config files
+// are still recognized and are treated as context named "default".
 type configData struct {
-	Mail     *string `yaml:"mail"`
-	Password *string `yaml:"password"`
-	Device   *string `yaml:"device"`
+	contextData    `yaml:",inline"`
+	CurrentContext string                  `yaml:"current-context,omitempty"`
+	SecretStore    string                  `yaml:"secret-store,omitempty"`
+	Contexts       map[string]*contextData `yaml:"contexts,omitempty"`
+	Notifications  []notificationConfig    `yaml:"notifications,omitempty"`
+	Api            *apiConfig              `yaml:"api,omitempty"`
+}
+
+func (cfg *configData) resolveContextName(name string) string {
+	if len(name) == 0 {
+		name = os.Getenv("JD_CONTEXT")
+	}
+	if len(name) == 0 {
+		name = cfg.CurrentContext
+	}
+	if len(name) == 0 {
+		name = defaultContextName
+	}
+	return name
 }
 
-func getClient(debug bool) (jdownloader.JdClient, error) {
-	cfg, err := loadConfig()
+func (cfg *configData) context(name string) (*contextData, error) {
+	name = cfg.resolveContextName(name)
+	ctx, ok := cfg.Contexts[name]
+	if !ok {
+		return nil, withExitCode(exitCodeNotFound, fmt.Errorf("context '%s' does not exist", name))
+	}
+	return ctx, nil
+}
+
+func loadConfig(name string) (*contextData, error) {
+	cfg, err := readConfig()
 	if err != nil {
 		return nil, err
 	}
-	var (
-		logger *slog.Logger
-	)
-	level := "info"
-	if debug {
-		level = "debug"
-	}
-	logger = xlog.MustNew(level, xlog.LogFormatLogFmt).Logger()
-	return jdownloader.NewClient(*cfg.Mail, *cfg.Password, logger,
-		jdownloader.ClientOptionTimeout(30*time.Second),
-		jdownloader.ClientOptionAppKey("jdcli")), nil
+	return cfg.credentials(cfg.resolveContextName(name))
 }
 
-func loadConfig() (*configData, error) {
-	var cfg configData
-	cfgDir, err := getConfigPath()
+// credentials returns named context with password resolved from secret store, if one is configured.
+func (cfg *configData) credentials(name string) (*contextData, error) {
+	ctx, err := cfg.context(name)
 	if err != nil {
 		return nil, err
 	}
-	data, err := os.ReadFile(cfgDir)
+	if ctx.Password == nil && !isPlainSecretStore(cfg.SecretStore) {
+		store, err := newSecretStore(cfg.SecretStore)
+		if err != nil {
+			return nil, err
+		}
+		password, err := store.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Password = &password
+	}
+	if ctx.Mail == nil || ctx.Password == nil || len(*ctx.Mail) == 0 || len(*ctx.Password) == 0 {
+		return nil, withExitCode(exitCodeAuth, errors.New("credentials are not specified. Use 'jdcli login' to populate them"))
+	}
+	return ctx, nil
+}
+
+// readConfig reads config file, missing file results in empty config.
+func readConfig() (*configData, error) {
+	var cfg configData
+	cfgPath, err := getConfigPath()
 	if err != nil {
 		return nil, err
 	}
+	data, err := os.ReadFile(cfgPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
 	err = yaml.Unmarshal(data, &cfg)
 	if err != nil {
 		return nil, err
 	}
-	if len(*cfg.Mail) == 0 || len(*cfg.Password) == 0 {
-		return nil, errors.New("credentials are not specified. Use 'jdcli login' to populate them")
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]*contextData)
+	}
+	if cfg.Mail != nil || cfg.Password != nil || cfg.Device != nil {
+		if _, ok := cfg.Contexts[defaultContextName]; !ok {
+			legacy := cfg.contextData
+			cfg.Contexts[defaultContextName] = &legacy
+			if len(cfg.CurrentContext) == 0 {
+				cfg.CurrentContext = defaultContextName
+			}
+		}
+		cfg.contextData = contextData{}
 	}
 	return &cfg, nil
 }
@@ -81,7 +136,32 @@ func saveConfig(cfg *configData) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(cfgPath, data, 0o644)
+	// WriteFile keeps mode of existing file, config may hold passwords so it must not stay readable by others
+	if err = os.Chmod(cfgPath, 0o600); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.WriteFile(cfgPath, data, 0o600)
+}
+
+// setPassword stores password of named context either in configured secret store or in config itself.
+func (cfg *configData) setPassword(name string, password *string) error {
+	ctx, ok := cfg.Contexts[name]
+	if !ok {
+		return withExitCode(exitCodeNotFound, fmt.Errorf("context '%s' does not exist", name))
+	}
+	if isPlainSecretStore(cfg.SecretStore) {
+		ctx.Password = password
+		return nil
+	}
+	store, err := newSecretStore(cfg.SecretStore)
+	if err != nil {
+		return err
+	}
+	ctx.Password = nil
+	if password == nil {
+		return store.Delete(name)
+	}
+	return store.Set(name, *password)
 }
 
 func getConfigPath() (string, error) {
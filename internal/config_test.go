@@ -0,0 +1,139 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLegacyConfig(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "jdconfig.yaml")
+	t.Setenv("JD_CONFIG", cfgPath)
+	t.Setenv("JD_CONTEXT", "")
+	assert.NoError(t, os.WriteFile(cfgPath, []byte("mail: me@example.com\npassword: secret\n"), 0o600))
+
+	ctx, err := loadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, "me@example.com", *ctx.Mail)
+
+	cfg, err := readConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultContextName, cfg.CurrentContext)
+	assert.Nil(t, cfg.Mail)
+}
+
+func TestConfigContexts(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	t.Setenv("JD_CONTEXT", "")
+
+	cfg, err := readConfig()
+	assert.NoError(t, err)
+	mail, pass := "shared@example.com", "secret"
+	cfg.Contexts["shared"] = &contextData{Mail: &mail, Password: &pass}
+	cfg.Contexts["personal"] = &contextData{}
+	cfg.CurrentContext = "personal"
+	assert.NoError(t, saveConfig(cfg))
+
+	_, err = loadConfig("")
+	assert.Error(t, err)
+	_, err = loadConfig("missing")
+	assert.Error(t, err)
+	ctx, err := loadConfig("shared")
+	assert.NoError(t, err)
+	assert.Equal(t, mail, *ctx.Mail)
+
+	t.Setenv("JD_CONTEXT", "shared")
+	_, err = loadConfig("")
+	assert.NoError(t, err)
+}
+
+func TestDeleteContext(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	t.Setenv(secretsPassEnvName, "passphrase")
+	cfg, err := readConfig()
+	assert.NoError(t, err)
+	mail, pass := "me@example.com", "secret"
+	cfg.SecretStore = secretStoreFile
+	cfg.Contexts["work"] = &contextData{Mail: &mail}
+	assert.NoError(t, cfg.setPassword("work", &pass))
+	assert.NoError(t, saveConfig(cfg))
+	ctx, err := cfg.credentials("work")
+	assert.NoError(t, err)
+	assert.NoError(t, storeSession("work", ctx, &jdownloader.Session{}))
+	cache, err := readSessionCache()
+	assert.NoError(t, err)
+	cache["work"].Expires = time.Now().Add(-time.Minute)
+	assert.NoError(t, writeSessionCache(cache))
+
+	root := NewRootCommand(nil, io.Discard, io.Discard)
+	root.SetArgs([]string{"config", "delete-context", "work"})
+	assert.NoError(t, root.Execute())
+	cfg, err = readConfig()
+	assert.NoError(t, err)
+	assert.NotContains(t, cfg.Contexts, "work")
+	cache, err = readSessionCache()
+	assert.NoError(t, err)
+	assert.Empty(t, cache)
+	store, err := newSecretStore(secretStoreFile)
+	assert.NoError(t, err)
+	_, err = store.Get("work")
+	assert.Error(t, err)
+}
+
+func TestSaveConfigRestrictsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file mode is not supported on windows")
+	}
+	cfgPath := filepath.Join(t.TempDir(), "jdconfig.yaml")
+	t.Setenv("JD_CONFIG", cfgPath)
+	assert.NoError(t, os.WriteFile(cfgPath, []byte("contexts: {}\n"), 0o644))
+	cfg, err := readConfig()
+	assert.NoError(t, err)
+	assert.NoError(t, saveConfig(cfg))
+	fi, err := os.Stat(cfgPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+}
+
+func TestMigrateSecrets(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	t.Setenv(secretsPassEnvName, "passphrase")
+	cfg, err := readConfig()
+	assert.NoError(t, err)
+	mail, pass := "me@example.com", "secret"
+	cfg.Contexts["work"] = &contextData{Mail: &mail, Password: &pass}
+	assert.NoError(t, saveConfig(cfg))
+
+	root := NewRootCommand(nil, io.Discard, io.Discard)
+	root.SetArgs([]string{"config", "migrate-secrets", "--to", secretStoreFile})
+	assert.NoError(t, root.Execute())
+	cfg, err = readConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, secretStoreFile, cfg.SecretStore)
+	assert.Nil(t, cfg.Contexts["work"].Password)
+	ctx, err := cfg.credentials("work")
+	assert.NoError(t, err)
+	assert.Equal(t, pass, *ctx.Password)
+}
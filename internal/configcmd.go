@@ -0,0 +1,261 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type namedContext struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	Mail    string `json:"mail,omitempty"`
+	Device  string `json:"device,omitempty"`
+}
+
+var ctxCols = []column[namedContext]{
+	{name: "Current", value: func(c namedContext) string {
+		if c.Current {
+			return "*"
+		}
+		return ""
+	}},
+	{name: "Name", value: func(c namedContext) string { return c.Name }},
+	{name: "Mail", value: func(c namedContext) string { return c.Mail }},
+	{name: "Device", value: func(c namedContext) string { return c.Device }},
+}
+
+func newConfigCommand(out io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "Manages config file contexts",
+	}
+	c.AddCommand(newConfigGetContextsCommand(out))
+	c.AddCommand(newConfigUseContextCommand(out))
+	c.AddCommand(newConfigSetContextCommand(out))
+	c.AddCommand(newConfigDeleteContextCommand(out))
+	c.AddCommand(newConfigMigrateSecretsCommand(out))
+	return c
+}
+
+func newConfigGetContextsCommand(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List all contexts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(cfg.Contexts))
+			for name := range cfg.Contexts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			ctxs := make([]namedContext, len(names))
+			for i, name := range names {
+				ctxs[i] = namedContext{
+					Name:    name,
+					Current: name == cfg.CurrentContext,
+					Mail:    strVal(cfg.Contexts[name].Mail),
+					Device:  strVal(cfg.Contexts[name].Device),
+				}
+			}
+			return printList(cliContextOf(cmd).printer(), ctxs, ctxCols)
+		},
+	}
+}
+
+func newConfigUseContextCommand(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Set current context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			if _, err = cfg.context(args[0]); err != nil {
+				return err
+			}
+			cfg.CurrentContext = args[0]
+			if err = saveConfig(cfg); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Switched to context \"%s\"\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newConfigSetContextCommand(out io.Writer) *cobra.Command {
+	var (
+		mail   string
+		device string
+	)
+	c := &cobra.Command{
+		Use:   "set-context NAME",
+		Short: "Create context or update its properties. Use 'jdcli login --context NAME' to set credentials",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			ctx, ok := cfg.Contexts[args[0]]
+			if !ok {
+				ctx = &contextData{}
+				cfg.Contexts[args[0]] = ctx
+			}
+			if cmd.Flags().Changed("mail") {
+				ctx.Mail = &mail
+			}
+			if cmd.Flags().Changed("device") {
+				ctx.Device = &device
+			}
+			if len(cfg.CurrentContext) == 0 {
+				cfg.CurrentContext = args[0]
+			}
+			if err = saveConfig(cfg); err != nil {
+				return err
+			}
+			if ok {
+				fmt.Fprintf(out, "Context \"%s\" modified\n", args[0])
+			} else {
+				fmt.Fprintf(out, "Context \"%s\" created\n", args[0])
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVar(&mail, "mail", mail, "Account username/email")
+	c.Flags().StringVar(&device, "device", device, "Default device name")
+	return c
+}
+
+func newConfigDeleteContextCommand(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-context NAME",
+		Short: "Delete context from config file, together with its password and cached session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			if _, err = cfg.context(args[0]); err != nil {
+				return err
+			}
+			cc := cliContextOf(cmd)
+			if err = dropSession(cc, cfg, args[0]); err != nil {
+				cc.getLogger().Warn("unable to drop cached session", "context", args[0], "error", err)
+			}
+			if err = cfg.setPassword(args[0], nil); err != nil {
+				cc.getLogger().Warn("unable to delete password from secret store", "context", args[0], "error", err)
+			}
+			delete(cfg.Contexts, args[0])
+			if cfg.CurrentContext == args[0] {
+				cfg.CurrentContext = ""
+			}
+			if err = saveConfig(cfg); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Deleted context \"%s\"\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newConfigMigrateSecretsCommand(out io.Writer) *cobra.Command {
+	var to string
+	c := &cobra.Command{
+		Use:   "migrate-secrets",
+		Short: "Move passwords from config file (or from previously used secret store) into secret store",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			if len(to) == 0 {
+				to = cfg.SecretStore
+			}
+			if isPlainSecretStore(to) {
+				return fmt.Errorf("target secret store is not specified (use --to %s)", strings.Join(secretStores[1:], "|"))
+			}
+			store, err := newSecretStore(to)
+			if err != nil {
+				return err
+			}
+			var prev secretStore
+			if !isPlainSecretStore(cfg.SecretStore) && cfg.SecretStore != to {
+				if prev, err = newSecretStore(cfg.SecretStore); err != nil {
+					return err
+				}
+			}
+			names := make([]string, 0, len(cfg.Contexts))
+			for name := range cfg.Contexts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			migrated := 0
+			fromPrev := make([]string, 0)
+			for _, name := range names {
+				ctx := cfg.Contexts[name]
+				password := ctx.Password
+				if password == nil && prev != nil && ctx.Mail != nil {
+					secret, err := prev.Get(name)
+					if err != nil {
+						return err
+					}
+					password = &secret
+				}
+				if password == nil {
+					continue
+				}
+				if err = store.Set(name, *password); err != nil {
+					return err
+				}
+				if ctx.Password == nil && prev != nil {
+					fromPrev = append(fromPrev, name)
+				}
+				ctx.Password = nil
+				migrated++
+			}
+			cfg.SecretStore = to
+			if err = saveConfig(cfg); err != nil {
+				return err
+			}
+			// secrets are removed from previous store only once config points to new one,
+			// so that failed migration never leaves context without password
+			for _, name := range fromPrev {
+				if err = prev.Delete(name); err != nil {
+					cliContextOf(cmd).getLogger().Warn("unable to delete secret from previous secret store",
+						"context", name, "error", err)
+				}
+			}
+			fmt.Fprintf(out, "%d secret(s) migrated to %s secret store\n", migrated, to)
+			return nil
+		},
+	}
+	c.Flags().StringVar(&to, "to", to, fmt.Sprintf("Target secret store. One of: %s", strings.Join(secretStores[1:], "|")))
+	return c
+}
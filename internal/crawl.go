@@ -0,0 +1,159 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+)
+
+// crawlWaitOptions controls waiting for link crawler after links were added.
+type crawlWaitOptions struct {
+	wait          bool
+	timeout       time.Duration
+	poll          time.Duration
+	confirmOnline bool
+}
+
+// crawlJobId extracts identifier of crawler job from response data of add links call.
+func crawlJobId(data interface{}) (int64, bool) {
+	var raw interface{}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		raw = v["id"]
+	case json.RawMessage:
+		var job struct {
+			Id json.Number `json:"id"`
+		}
+		if err := json.Unmarshal(v, &job); err != nil {
+			return 0, false
+		}
+		raw = job.Id
+	default:
+		raw = data
+	}
+	switch id := raw.(type) {
+	case float64:
+		return int64(id), true
+	case int64:
+		return id, true
+	case json.Number:
+		n, err := id.Int64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(id, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// crawlSettled tells whether none of jobs is crawling or checking availability anymore.
+func crawlSettled(jobs []jdownloader.LinkCrawlerJob) bool {
+	for _, job := range jobs {
+		if (job.Crawling != nil && *job.Crawling) || (job.Checking != nil && *job.Checking) {
+			return false
+		}
+	}
+	return true
+}
+
+// newCrawledLinks returns links which are not among known link UUIDs.
+func newCrawledLinks(links []jdownloader.CrawledLink, known map[int64]bool) []jdownloader.CrawledLink {
+	res := make([]jdownloader.CrawledLink, 0)
+	for _, link := range links {
+		if link.Uuid != nil && !known[*link.Uuid] {
+			res = append(res, link)
+		}
+	}
+	return res
+}
+
+// waitForCrawl polls crawler job until it settles, then prints collected packages and links.
+// Links which are online are moved to download list when requested.
+func waitForCrawl(ctx context.Context, out io.Writer, dev jdownloader.Device, jobId int64, known map[int64]bool, opts *crawlWaitOptions, autoStart bool) error {
+	lg := dev.LinkGrabber()
+	var deadline time.Time
+	if opts.timeout > 0 {
+		deadline = time.Now().Add(opts.timeout)
+	}
+	for {
+		jobs, err := lg.QueryCrawlerJobs([]int64{jobId})
+		if err != nil {
+			return err
+		}
+		if crawlSettled(*jobs) {
+			break
+		}
+		if deadlinePassed(deadline) {
+			return withExitCode(exitCodeTimeout, fmt.Errorf("crawler job %d did not finish within %s", jobId, opts.timeout))
+		}
+		if err = sleepCtx(ctx, pollDelay(opts.poll, deadline)); err != nil {
+			return err
+		}
+	}
+	all, err := lg.Links()
+	if err != nil {
+		return err
+	}
+	links := newCrawledLinks(*all, known)
+	if len(links) == 0 {
+		fmt.Fprintf(out, "No links were collected\n")
+		return nil
+	}
+	pkgs, err := lg.Packages()
+	if err != nil {
+		return err
+	}
+	pkgIds := make(map[int64]bool)
+	online := make([]int64, 0)
+	for _, link := range links {
+		if link.PackageUuid != nil {
+			pkgIds[*link.PackageUuid] = true
+		}
+		if strings.EqualFold(strVal(link.Availability), availabilityOnline) {
+			online = append(online, *link.Uuid)
+		}
+	}
+	collected := make([]jdownloader.CrawledPackage, 0)
+	for _, pkg := range *pkgs {
+		if pkg.Uuid != nil && pkgIds[*pkg.Uuid] {
+			collected = append(collected, pkg)
+		}
+	}
+	if err = printTable(out, collected, clPkgCols, false); err != nil {
+		return err
+	}
+	cols, err := pickColumns(clCols, []string{"id", "name", "host", "availability", "size", "package"})
+	if err != nil {
+		return err
+	}
+	if err = printTable(out, links, cols, false); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d of %d links are online\n", len(online), len(links))
+	if opts.confirmOnline && len(online) > 0 {
+		return confirmLinks(out, dev, online, []int64{}, autoStart)
+	}
+	return nil
+}
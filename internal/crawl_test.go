@@ -0,0 +1,47 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrawlJobId(t *testing.T) {
+	id, ok := crawlJobId(map[string]interface{}{"id": float64(1234)})
+	assert.True(t, ok)
+	assert.Equal(t, int64(1234), id)
+	id, ok = crawlJobId(json.RawMessage(`{"id": 42}`))
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), id)
+	_, ok = crawlJobId(nil)
+	assert.False(t, ok)
+}
+
+func TestCrawlSettled(t *testing.T) {
+	assert.True(t, crawlSettled(nil))
+	assert.True(t, crawlSettled([]jdownloader.LinkCrawlerJob{{Crawling: pbool(false), Checking: pbool(false)}}))
+	assert.False(t, crawlSettled([]jdownloader.LinkCrawlerJob{{Crawling: pbool(false), Checking: pbool(true)}}))
+}
+
+func TestNewCrawledLinks(t *testing.T) {
+	links := crawledLinks()
+	assert.Equal(t, []int64{3, 4}, uuids(newCrawledLinks(links, map[int64]bool{1: true, 2: true})))
+}
@@ -0,0 +1,480 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const (
+	crawlJobFormatJson       = "json"
+	crawlJobFormatProperties = "properties"
+)
+
+var unsafeFileNameRe = regexp.MustCompile(`[^\w\-. ]+`)
+
+// crawlBool is boolean of crawljob file, JDownloader writes it as "TRUE", "FALSE" or "UNSET".
+type crawlBool string
+
+const (
+	crawlTrue  crawlBool = "TRUE"
+	crawlFalse crawlBool = "FALSE"
+)
+
+func (b *crawlBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = parseCrawlBool(fmt.Sprint(v))
+	return nil
+}
+
+func parseCrawlBool(s string) crawlBool {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "TRUE":
+		return crawlTrue
+	case "FALSE":
+		return crawlFalse
+	default:
+		return ""
+	}
+}
+
+func toCrawlBool(b *bool) crawlBool {
+	switch {
+	case b == nil:
+		return ""
+	case *b:
+		return crawlTrue
+	default:
+		return crawlFalse
+	}
+}
+
+// crawlJob is single job of JDownloader folder watch (.crawljob) file.
+type crawlJob struct {
+	Text                       string    `json:"text"`
+	PackageName                string    `json:"packageName,omitempty"`
+	Filename                   string    `json:"filename,omitempty"`
+	Comment                    string    `json:"comment,omitempty"`
+	DownloadFolder             string    `json:"downloadFolder,omitempty"`
+	Priority                   string    `json:"priority,omitempty"`
+	DownloadPassword           string    `json:"downloadPassword,omitempty"`
+	ExtractPasswords           []string  `json:"extractPasswords,omitempty"`
+	Enabled                    crawlBool `json:"enabled,omitempty"`
+	AutoStart                  crawlBool `json:"autoStart,omitempty"`
+	AutoConfirm                crawlBool `json:"autoConfirm,omitempty"`
+	ForcedStart                crawlBool `json:"forcedStart,omitempty"`
+	ExtractAfterDownload       crawlBool `json:"extractAfterDownload,omitempty"`
+	DeepAnalyseEnabled         crawlBool `json:"deepAnalyseEnabled,omitempty"`
+	AddOfflineLink             crawlBool `json:"addOfflineLink,omitempty"`
+	OverwritePackagizerEnabled crawlBool `json:"overwritePackagizerEnabled,omitempty"`
+	Chunks                     int       `json:"chunks,omitempty"`
+}
+
+// addOptions maps job onto options of add links call, fields which can't be mapped are reported as ignored.
+func (j *crawlJob) addOptions() ([]jdownloader.AddLinksOptions, []string) {
+	opts := []jdownloader.AddLinksOptions{
+		jdownloader.AddLinksOptionAutostart(j.AutoStart == crawlTrue || j.AutoConfirm == crawlTrue),
+	}
+	ignored := make([]string, 0)
+	if len(j.PackageName) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionPackage(j.PackageName))
+	}
+	if len(j.DownloadFolder) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionDestinationDir(j.DownloadFolder))
+	}
+	if len(j.Priority) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionPriority(strings.ToUpper(j.Priority)))
+	}
+	if len(j.DownloadPassword) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionDownloadPassword(j.DownloadPassword))
+	}
+	if len(j.ExtractPasswords) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionExtractPassword(j.ExtractPasswords[0]))
+		if len(j.ExtractPasswords) > 1 {
+			ignored = append(ignored, "extractPasswords (all but first)")
+		}
+	}
+	if len(j.DeepAnalyseEnabled) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionDeepDecrypt(j.DeepAnalyseEnabled == crawlTrue))
+	}
+	if len(j.OverwritePackagizerEnabled) > 0 {
+		opts = append(opts, jdownloader.AddLinksOptionOverwritePackagizerRules(j.OverwritePackagizerEnabled == crawlTrue))
+	}
+	for name, set := range map[string]bool{
+		"filename":             len(j.Filename) > 0,
+		"comment":              len(j.Comment) > 0,
+		"enabled":              j.Enabled == crawlFalse,
+		"forcedStart":          j.ForcedStart == crawlTrue,
+		"extractAfterDownload": len(j.ExtractAfterDownload) > 0,
+		"addOfflineLink":       len(j.AddOfflineLink) > 0,
+		"chunks":               j.Chunks > 0,
+	} {
+		if set {
+			ignored = append(ignored, name)
+		}
+	}
+	slices.Sort(ignored)
+	return opts, ignored
+}
+
+// parseCrawlJobs parses crawljob file either in JSON format (single job or array of jobs),
+// or in key=value format where jobs are separated by empty lines.
+func parseCrawlJobs(data []byte) ([]crawlJob, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var jobs []crawlJob
+		if err := json.Unmarshal(trimmed, &jobs); err != nil {
+			return nil, err
+		}
+		return jobs, nil
+	}
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var job crawlJob
+		if err := json.Unmarshal(trimmed, &job); err != nil {
+			return nil, err
+		}
+		return []crawlJob{job}, nil
+	}
+	jobs := make([]crawlJob, 0)
+	var job *crawlJob
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for num := 1; sc.Scan(); num++ {
+		line := strings.TrimSpace(sc.Text())
+		if len(line) == 0 {
+			job = nil
+			continue
+		}
+		if line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key=value", num)
+		}
+		if job == nil {
+			jobs = append(jobs, crawlJob{})
+			job = &jobs[len(jobs)-1]
+		}
+		if err := job.set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+	}
+	return jobs, sc.Err()
+}
+
+func (j *crawlJob) set(key, value string) error {
+	switch strings.ToLower(key) {
+	case "text":
+		j.Text = value
+	case "packagename":
+		j.PackageName = value
+	case "filename":
+		j.Filename = value
+	case "comment":
+		j.Comment = value
+	case "downloadfolder":
+		j.DownloadFolder = value
+	case "priority":
+		j.Priority = value
+	case "downloadpassword":
+		j.DownloadPassword = value
+	case "extractpasswords":
+		if strings.HasPrefix(value, "[") {
+			return json.Unmarshal([]byte(value), &j.ExtractPasswords)
+		}
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.TrimSpace(p); len(p) > 0 {
+				j.ExtractPasswords = append(j.ExtractPasswords, p)
+			}
+		}
+	case "enabled":
+		j.Enabled = parseCrawlBool(value)
+	case "autostart":
+		j.AutoStart = parseCrawlBool(value)
+	case "autoconfirm":
+		j.AutoConfirm = parseCrawlBool(value)
+	case "forcedstart":
+		j.ForcedStart = parseCrawlBool(value)
+	case "extractafterdownload":
+		j.ExtractAfterDownload = parseCrawlBool(value)
+	case "deepanalyseenabled":
+		j.DeepAnalyseEnabled = parseCrawlBool(value)
+	case "addofflinelink":
+		j.AddOfflineLink = parseCrawlBool(value)
+	case "overwritepackagizerenabled":
+		j.OverwritePackagizerEnabled = parseCrawlBool(value)
+	case "chunks":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid chunks '%s'", value)
+		}
+		j.Chunks = n
+	}
+	return nil
+}
+
+// writeProperties writes job in key=value format, links are separated by space.
+func (j *crawlJob) writeProperties(w io.Writer) error {
+	var sb strings.Builder
+	put := func(key, value string) {
+		if len(value) > 0 {
+			fmt.Fprintf(&sb, "%s=%s\n", key, value)
+		}
+	}
+	put("text", strings.Join(strings.Fields(j.Text), " "))
+	put("packageName", j.PackageName)
+	put("comment", j.Comment)
+	put("downloadFolder", j.DownloadFolder)
+	put("priority", j.Priority)
+	put("downloadPassword", j.DownloadPassword)
+	if len(j.ExtractPasswords) > 0 {
+		data, err := json.Marshal(j.ExtractPasswords)
+		if err != nil {
+			return err
+		}
+		put("extractPasswords", string(data))
+	}
+	put("enabled", string(j.Enabled))
+	put("autoStart", string(j.AutoStart))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// packageCrawlJobs converts download packages and their links into crawl jobs.
+func packageCrawlJobs(pkgs []jdownloader.FilePackage, links []jdownloader.DownloadLink) []crawlJob {
+	urls := make(map[int64][]string)
+	for _, link := range links {
+		if link.PackageUuid != nil && link.Url != nil {
+			urls[*link.PackageUuid] = append(urls[*link.PackageUuid], *link.Url)
+		}
+	}
+	jobs := make([]crawlJob, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Uuid == nil || len(urls[*pkg.Uuid]) == 0 {
+			continue
+		}
+		jobs = append(jobs, crawlJob{
+			Text:           strings.Join(urls[*pkg.Uuid], "\n"),
+			PackageName:    strVal(pkg.Name),
+			Comment:        strVal(pkg.Comment),
+			DownloadFolder: strVal(pkg.SaveTo),
+			Priority:       strVal(pkg.Priority),
+			Enabled:        toCrawlBool(pkg.Enabled),
+			AutoStart:      crawlFalse,
+		})
+	}
+	return jobs
+}
+
+func writeCrawlJobs(w io.Writer, jobs []crawlJob, format string) error {
+	if format == crawlJobFormatJson {
+		return printJson(w, jobs)
+	}
+	for i := range jobs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := jobs[i].writeProperties(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newImportCrawlJobCommand(out io.Writer) *cobra.Command {
+	type importData struct {
+		dryRun bool
+	}
+	var data importData
+	c := &cobra.Command{
+		Use:   "import-crawljob FILE...",
+		Short: "Add links described by folder watch (.crawljob) files to LinkCollector",
+		Long: `Add links described by folder watch (.crawljob) files to LinkCollector.
+Both key=value and JSON formats are supported. Fields which can't be passed to JDownloader when adding links
+(filename, comment, chunks, ...) are reported and ignored.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			type fileJob struct {
+				file  string
+				job   crawlJob
+				links []string
+			}
+			jobs := make([]fileJob, 0)
+			for _, file := range args {
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				parsed, err := parseCrawlJobs(content)
+				if err != nil {
+					return fmt.Errorf("%s: %w", file, err)
+				}
+				for _, job := range parsed {
+					links, _, err := parseLinks(strings.NewReader(job.Text), file)
+					if err != nil {
+						return err
+					}
+					if len(links) == 0 {
+						fmt.Fprintf(out, "%s: job without links skipped\n", file)
+						continue
+					}
+					jobs = append(jobs, fileJob{file: file, job: job, links: dedupLinks(links)})
+				}
+			}
+			if len(jobs) == 0 {
+				return withExitCode(exitCodeNotFound, errors.New("no links found in crawljob files"))
+			}
+			if data.dryRun {
+				for _, j := range jobs {
+					_, ignored := j.job.addOptions()
+					fmt.Fprintf(out, "%s: %d links into package '%s'", j.file, len(j.links), j.job.PackageName)
+					if len(ignored) > 0 {
+						fmt.Fprintf(out, " (ignored: %s)", strings.Join(ignored, ", "))
+					}
+					fmt.Fprintln(out)
+				}
+				return nil
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				for _, j := range jobs {
+					opts, ignored := j.job.addOptions()
+					if len(ignored) > 0 {
+						fmt.Fprintf(out, "%s: ignored fields: %s\n", j.file, strings.Join(ignored, ", "))
+					}
+					if _, err := dev.LinkGrabber().Add(j.links, opts...); err != nil {
+						return fmt.Errorf("%s: %w", j.file, err)
+					}
+					fmt.Fprintf(out, "%s: %d links added\n", j.file, len(j.links))
+				}
+				return nil
+			})
+		},
+	}
+	c.Flags().BoolVar(&data.dryRun, "dry-run", data.dryRun, "Only show what would be added")
+	return c
+}
+
+func newExportCrawlJobCommand(out io.Writer) *cobra.Command {
+	type exportData struct {
+		name   string
+		format string
+		dir    string
+	}
+	var data exportData
+	data.format = crawlJobFormatJson
+	c := &cobra.Command{
+		Use:   "export-crawljob [PACKAGE...]",
+		Short: "Export download packages as folder watch (.crawljob) jobs",
+		Long: `Export download packages (all packages when none is selected) as folder watch (.crawljob) jobs.
+Jobs are written to standard output, or as one file per package into directory given by --dir.
+Files are named after packages, UUID of package is appended when names of more packages collide.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if data.format != crawlJobFormatJson && data.format != crawlJobFormatProperties {
+				return fmt.Errorf("unsupported format '%s', must be one of: %s|%s", data.format, crawlJobFormatJson, crawlJobFormatProperties)
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				pkgs, err := dl.Packages()
+				if err != nil {
+					return err
+				}
+				links, err := dl.Links()
+				if err != nil {
+					return err
+				}
+				selected, err := selectExportPackages(*pkgs, args, data.name)
+				if err != nil {
+					return err
+				}
+				if len(data.dir) == 0 {
+					return writeCrawlJobs(out, packageCrawlJobs(selected, *links), data.format)
+				}
+				names := crawlJobFileNames(selected)
+				for _, pkg := range selected {
+					jobs := packageCrawlJobs([]jdownloader.FilePackage{pkg}, *links)
+					if len(jobs) == 0 {
+						continue
+					}
+					path := filepath.Join(data.dir, names[*pkg.Uuid])
+					var buf bytes.Buffer
+					if err = writeCrawlJobs(&buf, jobs, data.format); err != nil {
+						return err
+					}
+					if err = os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+						return err
+					}
+					fmt.Fprintf(out, "%s written\n", path)
+				}
+				return nil
+			})
+		},
+	}
+	c.Flags().StringVar(&data.name, "name", data.name, "Select packages with name matching regular expression")
+	c.Flags().StringVar(&data.format, "format", data.format, fmt.Sprintf("Format of jobs. One of: %s|%s", crawlJobFormatJson, crawlJobFormatProperties))
+	c.Flags().StringVar(&data.dir, "dir", data.dir, "Directory where to write one .crawljob file per package")
+	return c
+}
+
+func crawlJobFileName(pkgName string) string {
+	name := strings.TrimSpace(unsafeFileNameRe.ReplaceAllString(pkgName, "_"))
+	if len(name) == 0 {
+		name = "package"
+	}
+	return name + ".crawljob"
+}
+
+// crawlJobFileNames maps UUID of packages to names of their job files.
+// Packages whose file names would collide (ignoring case) get their UUID appended to the name.
+func crawlJobFileNames(pkgs []jdownloader.FilePackage) map[int64]string {
+	counts := make(map[string]int)
+	for _, pkg := range pkgs {
+		if pkg.Uuid != nil {
+			counts[strings.ToLower(crawlJobFileName(strVal(pkg.Name)))]++
+		}
+	}
+	names := make(map[int64]string)
+	for _, pkg := range pkgs {
+		if pkg.Uuid == nil {
+			continue
+		}
+		name := crawlJobFileName(strVal(pkg.Name))
+		if counts[strings.ToLower(name)] > 1 {
+			name = fmt.Sprintf("%s-%d.crawljob", strings.TrimSuffix(name, ".crawljob"), *pkg.Uuid)
+		}
+		names[*pkg.Uuid] = name
+	}
+	return names
+}
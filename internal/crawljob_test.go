@@ -0,0 +1,87 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCrawlJobsProperties(t *testing.T) {
+	data, err := os.ReadFile("../testdata/jobs.crawljob")
+	assert.NoError(t, err)
+	jobs, err := parseCrawlJobs(data)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "Backup", jobs[0].PackageName)
+	assert.Equal(t, crawlTrue, jobs[0].AutoStart)
+	assert.Equal(t, []string{"secret", "other"}, jobs[0].ExtractPasswords)
+	assert.Equal(t, 2, jobs[0].Chunks)
+	opts, ignored := jobs[0].addOptions()
+	assert.Len(t, opts, 5)
+	assert.Equal(t, []string{"chunks", "extractPasswords (all but first)"}, ignored)
+	_, ignored = jobs[1].addOptions()
+	assert.Equal(t, []string{"enabled"}, ignored)
+}
+
+func TestParseCrawlJobsJson(t *testing.T) {
+	data, err := os.ReadFile("../testdata/job.json.crawljob")
+	assert.NoError(t, err)
+	jobs, err := parseCrawlJobs(data)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, crawlTrue, jobs[0].AutoConfirm)
+	assert.Equal(t, crawlFalse, jobs[0].DeepAnalyseEnabled)
+
+	jobs, err = parseCrawlJobs([]byte(`{"text": "https://example.com/e.zip"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/e.zip", jobs[0].Text)
+}
+
+func TestExportCrawlJobs(t *testing.T) {
+	pkgs := []jdownloader.FilePackage{
+		{Uuid: pint64(10), Name: pstr("Backup"), SaveTo: pstr("/data/backup"), Enabled: pbool(true)},
+		{Uuid: pint64(20), Name: pstr("Empty")},
+	}
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Url: pstr("https://example.com/a.zip")},
+		{Uuid: pint64(2), PackageUuid: pint64(10), Url: pstr("https://example.com/b.zip")},
+	}
+	jobs := packageCrawlJobs(pkgs, links)
+	assert.Len(t, jobs, 1)
+	var out bytes.Buffer
+	assert.NoError(t, writeCrawlJobs(&out, jobs, crawlJobFormatProperties))
+	parsed, err := parseCrawlJobs(out.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/a.zip https://example.com/b.zip", parsed[0].Text)
+	assert.Equal(t, "/data/backup", parsed[0].DownloadFolder)
+	assert.Equal(t, crawlTrue, parsed[0].Enabled)
+
+	out.Reset()
+	assert.NoError(t, writeCrawlJobs(&out, jobs, crawlJobFormatJson))
+	parsed, err = parseCrawlJobs(out.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, jobs, parsed)
+	assert.Equal(t, "a_b.crawljob", crawlJobFileName("a/b"))
+	assert.Equal(t, map[int64]string{1: "a_b-1.crawljob", 2: "A_B-2.crawljob", 3: "c.crawljob"}, crawlJobFileNames([]jdownloader.FilePackage{
+		{Uuid: pint64(1), Name: pstr("a/b")}, {Uuid: pint64(2), Name: pstr("A?B")}, {Uuid: pint64(3), Name: pstr("c")}, {Name: pstr("c")},
+	}))
+}
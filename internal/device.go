@@ -17,17 +17,19 @@ limitations under the License.
 package internal
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/rkosegi/jdownloader-go/jdownloader"
 	"github.com/spf13/cobra"
 )
 
-var devCols = []string{"ID", "Type", "Name", "Status"}
+var devCols = []column[jdownloader.DeviceInfo]{
+	{name: "ID", value: func(d jdownloader.DeviceInfo) string { return d.Id }},
+	{name: "Type", value: func(d jdownloader.DeviceInfo) string { return d.Type }},
+	{name: "Name", value: func(d jdownloader.DeviceInfo) string { return d.Name }},
+	{name: "Status", value: func(d jdownloader.DeviceInfo) string { return d.Status }},
+}
 
 func newDeviceCommand(out io.Writer) *cobra.Command {
 	c := &cobra.Command{
@@ -35,62 +37,71 @@ func newDeviceCommand(out io.Writer) *cobra.Command {
 		Short: "Manages devices",
 	}
 	c.AddCommand(newDeviceListCommand(out))
+	c.AddCommand(newDeviceUseCommand(out))
 	return c
 }
 
 func newDeviceListCommand(out io.Writer) *cobra.Command {
-	type listData struct {
-		debug bool
-		json  bool
-	}
-	var data listData
-	c := &cobra.Command{
+	return &cobra.Command{
 		Use:   "list",
 		Short: "List all devices",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c, err := getClient(data.debug)
+			cc := cliContextOf(cmd)
+			c, _, err := cc.getClient()
 			if err != nil {
 				return err
 			}
-			err = c.Connect()
+
+			devs, err := c.ListDevices()
 			if err != nil {
 				return err
 			}
-			defer func(client jdownloader.JdClient) {
-				err := client.Disconnect()
-				if err != nil {
-					fmt.Fprintf(out, "Failed to disconnect client: %v\n", err)
-				}
-			}(c)
+			return printList(cc.printer(), *devs, devCols)
+		},
+	}
+}
 
-			devs, err := c.ListDevices()
+func newDeviceUseCommand(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME|ID",
+		Short: "Set default device of current context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc := cliContextOf(cmd)
+			c, _, err := cc.getClient()
 			if err != nil {
 				return err
 			}
 
-			if data.json {
-				jsonObj, err := json.MarshalIndent(devs, "", "    ")
-				if err != nil {
-					return err
+			devs, err := c.ListDevices()
+			if err != nil {
+				return err
+			}
+			var found *jdownloader.DeviceInfo
+			for i, dev := range *devs {
+				if dev.Name == args[0] || dev.Id == args[0] {
+					found = &(*devs)[i]
+					break
 				}
-				fmt.Printf("%s\n", jsonObj)
-				return nil
+			}
+			if found == nil {
+				return withExitCode(exitCodeNotFound, fmt.Errorf("device '%s' not found, available devices: %s", args[0], deviceNames(*devs)))
 			}
 
-			tbl := tablewriter.NewWriter(os.Stdout)
-			tbl.Header(devCols)
-			for _, dev := range *devs {
-				row := make([]string, len(devCols))
-				row[0] = dev.Id
-				row[1] = dev.Type
-				row[2] = dev.Name
-				row[3] = dev.Status
-				tbl.Append(row)
+			cfg, err := readConfig()
+			if err != nil {
+				return err
 			}
-			return tbl.Render()
+			ctx, err := cfg.context(cc.context)
+			if err != nil {
+				return err
+			}
+			ctx.Device = &found.Name
+			if err = saveConfig(cfg); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Default device set to \"%s\"\n", found.Name)
+			return nil
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addJsonFlag(c.Flags(), &data.json)
-	return c
 }
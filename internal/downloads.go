@@ -17,27 +17,70 @@ limitations under the License.
 package internal
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"os"
-	"strconv"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/rkosegi/jdownloader-go/jdownloader"
 	"github.com/spf13/cobra"
 )
 
-var (
-	dlCols  = []string{"ID", "URL", "State", "ETA", "Speed", "Size"}
-	pkgCols = []string{"ID", "Name", "Status", "Save to", "Total size"}
+const (
+	linkOutcomeFinished = "finished"
+	linkOutcomeFailed   = "failed"
+	linkOutcomeOffline  = "offline"
 )
 
-type commonData struct {
-	debug  bool
-	device string
-}
+var (
+	dlCols = []column[jdownloader.DownloadLink]{
+		{name: "ID", value: func(l jdownloader.DownloadLink) string { return idVal(l.Uuid) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.Uuid) }},
+		{name: "Name", wide: true, value: func(l jdownloader.DownloadLink) string { return strVal(l.Name) }},
+		{name: "URL", value: func(l jdownloader.DownloadLink) string { return strVal(l.Url) }},
+		{name: "Host", wide: true, value: func(l jdownloader.DownloadLink) string { return strVal(l.Host) }},
+		{name: "State", value: func(l jdownloader.DownloadLink) string { return strVal(l.Status) }},
+		{name: "ETA", value: func(l jdownloader.DownloadLink) string { return formatEta(l.Eta) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.Eta) }},
+		{name: "Speed", value: func(l jdownloader.DownloadLink) string { return formatSpeed(l.Speed) },
+			key: func(l jdownloader.DownloadLink) any { return floatVal(l.Speed) }},
+		{name: "Loaded", wide: true, value: func(l jdownloader.DownloadLink) string { return formatSize(l.BytesLoaded) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.BytesLoaded) }},
+		{name: "Size", value: func(l jdownloader.DownloadLink) string { return formatSize(l.BytesTotal) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.BytesTotal) }},
+		{name: "Package", wide: true, value: func(l jdownloader.DownloadLink) string { return idVal(l.PackageUuid) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.PackageUuid) }},
+	}
+	dlExtraCols = []column[jdownloader.DownloadLink]{
+		{name: "Enabled", value: func(l jdownloader.DownloadLink) string { return boolVal(l.Enabled) }},
+		{name: "Priority", value: func(l jdownloader.DownloadLink) string { return strVal(l.Priority) }},
+		{name: "Comment", value: func(l jdownloader.DownloadLink) string { return strVal(l.Comment) }},
+		{name: "Added date", value: func(l jdownloader.DownloadLink) string { return formatDate(l.AddedDate) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.AddedDate) }},
+		{name: "Finished date", value: func(l jdownloader.DownloadLink) string { return formatDate(l.FinishedDate) },
+			key: func(l jdownloader.DownloadLink) any { return intVal(l.FinishedDate) }},
+	}
+	pkgCols = []column[jdownloader.FilePackage]{
+		{name: "ID", value: func(p jdownloader.FilePackage) string { return idVal(p.Uuid) },
+			key: func(p jdownloader.FilePackage) any { return intVal(p.Uuid) }},
+		{name: "Name", value: func(p jdownloader.FilePackage) string { return strVal(p.Name) }},
+		{name: "Status", value: func(p jdownloader.FilePackage) string { return strVal(p.Status) }},
+		{name: "Save to", value: func(p jdownloader.FilePackage) string { return strVal(p.SaveTo) }},
+		{name: "ETA", wide: true, value: func(p jdownloader.FilePackage) string { return formatEta(p.Eta) },
+			key: func(p jdownloader.FilePackage) any { return intVal(p.Eta) }},
+		{name: "Speed", wide: true, value: func(p jdownloader.FilePackage) string { return formatSpeed(p.Speed) },
+			key: func(p jdownloader.FilePackage) any { return floatVal(p.Speed) }},
+		{name: "Loaded", wide: true, value: func(p jdownloader.FilePackage) string { return formatSize(p.BytesLoaded) },
+			key: func(p jdownloader.FilePackage) any { return intVal(p.BytesLoaded) }},
+		{name: "Total size", value: func(p jdownloader.FilePackage) string { return formatSize(p.BytesTotal) },
+			key: func(p jdownloader.FilePackage) any { return intVal(p.BytesTotal) }},
+	}
+	pkgExtraCols = []column[jdownloader.FilePackage]{
+		{name: "Enabled", value: func(p jdownloader.FilePackage) string { return boolVal(p.Enabled) }},
+		{name: "Priority", value: func(p jdownloader.FilePackage) string { return strVal(p.Priority) }},
+		{name: "Comment", value: func(p jdownloader.FilePackage) string { return strVal(p.Comment) }},
+		{name: "Finished", value: func(p jdownloader.FilePackage) string { return boolVal(p.Finished) }},
+		{name: "Running", value: func(p jdownloader.FilePackage) string { return boolVal(p.Running) }},
+	}
+)
 
 func newDownloadsCommand(out io.Writer) *cobra.Command {
 	c := &cobra.Command{
@@ -51,6 +94,11 @@ func newDownloadsCommand(out io.Writer) *cobra.Command {
 	c.AddCommand(newDownloadPauseCommand(out))
 	c.AddCommand(newDownloadStopCommand(out))
 	c.AddCommand(newDownloadStartCommand(out))
+	c.AddCommand(newDownloadWaitCommand(out))
+	c.AddCommand(newDownloadTopCommand(out))
+	c.AddCommand(newDownloadWatchCommand(out))
+	c.AddCommand(newDownloadExportCommand(out))
+	c.AddCommand(newExportCrawlJobCommand(out))
 	return c
 }
 
@@ -66,76 +114,23 @@ func newDownloadLinksCommand(out io.Writer) *cobra.Command {
 
 func newDownloadLinkListCommand(out io.Writer) *cobra.Command {
 	type newData struct {
-		commonData
-		json bool
+		list listOptions
 	}
 	var data newData
 	c := &cobra.Command{
 		Use:   "list",
 		Short: "List downloads",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(device jdownloader.Device) error {
+			return doWithDevice(cmd, func(device jdownloader.Device) error {
 				links, err := device.Downloader().Links()
 				if err != nil {
 					return err
 				}
-
-				if data.json {
-					jsonObj, err := json.MarshalIndent(links, "", "    ")
-					if err != nil {
-						return err
-					}
-					fmt.Printf("%s\n", jsonObj)
-					return nil
-				}
-
-				tbl := tablewriter.NewWriter(os.Stdout)
-				tbl.Header(dlCols)
-
-				for _, link := range *links {
-					row := make([]string, len(dlCols))
-					row[0] = strconv.FormatUint(uint64(*link.Uuid), 10)
-					row[1] = compressUrl(*link.Url)
-					if link.Status != nil {
-						row[2] = *link.Status
-					}
-					row[3] = formatEta(link.Eta)
-					row[4] = formatSpeed(link.Speed)
-					row[5] = formatSize(link.BytesTotal)
-					tbl.Append(row)
-				}
-				return tbl.Render()
-			})
-		},
-	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	addJsonFlag(c.Flags(), &data.json)
-	return c
-}
-
-func newDownloadLinkRmCommand(out io.Writer) *cobra.Command {
-	type rmData struct {
-		commonData
-		id []int64
-	}
-	var data rmData
-	data.id = make([]int64, 0)
-	c := &cobra.Command{
-		Use:   "rm",
-		Short: "Remove one or more",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(data.id) == 0 {
-				return errors.New("no link identifier(s) was specified (use --ids id1 --ids id2 ... )")
-			}
-			return doWithDevice(data.debug, data.device, out, func(device jdownloader.Device) error {
-				return device.Downloader().Remove(data.id, []int64{})
+				return printListing(cliContextOf(cmd).printer(), data.list, *links, dlCols, dlExtraCols)
 			})
 		},
 	}
-	c.Flags().Int64SliceVar(&data.id, "id", data.id, "Link identifier")
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
+	addListFlags(c.Flags(), &data.list)
 	return c
 }
 
@@ -145,65 +140,39 @@ func newDownloadPackageCommand(out io.Writer) *cobra.Command {
 		Short: "Manages download packages",
 	}
 	c.AddCommand(newDownloadPackageListCommand(out))
+	c.AddCommand(newDownloadPackageRmCommand(out))
+	addPackageCommands(c, out, downloadPackages)
 	return c
 }
 
 func newDownloadPackageListCommand(out io.Writer) *cobra.Command {
 	type newData struct {
-		commonData
-		json bool
+		list listOptions
 	}
 	var data newData
 	c := &cobra.Command{
 		Use:   "list",
 		Short: "List download packages",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(device jdownloader.Device) error {
+			return doWithDevice(cmd, func(device jdownloader.Device) error {
 				pkgs, err := device.Downloader().Packages()
 				if err != nil {
 					return err
 				}
-
-				if data.json {
-					jsonObj, err := json.MarshalIndent(pkgs, "", "    ")
-					if err != nil {
-						return err
-					}
-					fmt.Printf("%s\n", jsonObj)
-					return nil
-				}
-
-				tbl := tablewriter.NewWriter(os.Stdout)
-				tbl.Header(pkgCols)
-
-				for _, pkg := range *pkgs {
-					row := make([]string, len(pkgCols))
-					row[0] = strconv.FormatUint(uint64(*pkg.Uuid), 10)
-					row[1] = *pkg.Name
-					if pkg.Status != nil {
-						row[2] = *pkg.Status
-					}
-					row[3] = *pkg.SaveTo
-					row[4] = formatSize(pkg.BytesTotal)
-					tbl.Append(row)
-				}
-				return tbl.Render()
+				return printListing(cliContextOf(cmd).printer(), data.list, *pkgs, pkgCols, pkgExtraCols)
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	addJsonFlag(c.Flags(), &data.json)
+	addListFlags(c.Flags(), &data.list)
 	return c
 }
 
 func newDownloadStatusCommand(out io.Writer) *cobra.Command {
-	var data commonData
-	c := &cobra.Command{
+	return &cobra.Command{
 		Use:   "status",
 		Short: "Show downloader status",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(device jdownloader.Device) error {
+			return doWithDevice(cmd, func(device jdownloader.Device) error {
 				si, err := device.Downloader().Speed()
 				if err != nil {
 					return err
@@ -218,99 +187,85 @@ func newDownloadStatusCommand(out io.Writer) *cobra.Command {
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	return c
-}
-
-func newDownloadCleanCommand(out io.Writer) *cobra.Command {
-	var data commonData
-	c := &cobra.Command{
-		Use:   "clean",
-		Short: "Clean completed downloads",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(dev jdownloader.Device) error {
-				dl := dev.Downloader()
-				links, err := dl.Links()
-				if err != nil {
-					return err
-				}
-				toRemove := make([]int64, 0)
-				for _, link := range *links {
-					if link.Status != nil && *link.Status == "Finished" {
-						fmt.Fprintf(out, "%s is completed and will be removed\n", *link.Url)
-						toRemove = append(toRemove, *link.Uuid)
-					}
-				}
-				if len(toRemove) > 0 {
-					err = dl.Remove(toRemove, []int64{})
-					if err != nil {
-						return err
-					} else {
-						fmt.Fprintf(out, "%d links cleaned\n", len(toRemove))
-					}
-				} else {
-					fmt.Fprintf(out, "Nothing to clean\n")
-				}
-				return nil
-			})
-		},
-	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	return c
 }
 
 func newDownloadPauseCommand(out io.Writer) *cobra.Command {
-	var data commonData
-	c := &cobra.Command{
+	return &cobra.Command{
 		Use:   "pause",
 		Short: "Pauses download",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(dev jdownloader.Device) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
 				res, err := dev.Downloader().Pause()
 				fmt.Fprintf(out, "Result : %t", res)
 				return err
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	return c
 }
 
 func newDownloadStopCommand(out io.Writer) *cobra.Command {
-	var data commonData
-	c := &cobra.Command{
+	return &cobra.Command{
 		Use:   "stop",
 		Short: "Stops download",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(dev jdownloader.Device) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
 				res, err := dev.Downloader().Stop()
 				fmt.Fprintf(out, "Result : %t", res)
 				return err
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	return c
 }
 
 func newDownloadStartCommand(out io.Writer) *cobra.Command {
-	var data commonData
-	c := &cobra.Command{
+	return &cobra.Command{
 		Use:   "start",
 		Short: "Starts a download",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(dev jdownloader.Device) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
 				res, err := dev.Downloader().Stop()
 				fmt.Fprintf(out, "Result : %t", res)
 				return err
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	return c
+}
+
+func isLinkFinished(link jdownloader.DownloadLink) bool {
+	return link.Finished != nil && *link.Finished
+}
+
+// linkOutcome classifies settled link as finished, offline or failed,
+// empty string is returned for links which are still in progress.
+func linkOutcome(link jdownloader.DownloadLink) string {
+	switch {
+	case isLinkFinished(link):
+		return linkOutcomeFinished
+	case link.StatusIconKey != nil && *link.StatusIconKey == "false":
+		return linkOutcomeOffline
+	case link.Skipped != nil && *link.Skipped:
+		return linkOutcomeFailed
+	default:
+		return ""
+	}
+}
+
+// isLinkFailed tells whether link ended up in state which won't change without user intervention,
+// like offline file, plugin error or link skipped by user. Offline links are failed ones as well.
+func isLinkFailed(link jdownloader.DownloadLink) bool {
+	outcome := linkOutcome(link)
+	return outcome == linkOutcomeFailed || outcome == linkOutcomeOffline
+}
+
+// matchPackages resolves package selectors (UUIDs or names) into set of package UUIDs.
+func matchPackages(pkgs []jdownloader.FilePackage, selectors []string) (map[int64]bool, error) {
+	ids, err := matchPackageRefs(filePackageRefs(pkgs), selectors, "")
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		res[id] = true
+	}
+	return res, nil
 }
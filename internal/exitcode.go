@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	exitCodeError          = 1
+	exitCodeUsage          = 2
+	exitCodeAuth           = 3
+	exitCodeNoDevice       = 4
+	exitCodeDeviceOffline  = 5
+	exitCodeNotFound       = 6
+	exitCodePartialFailure = 7
+	exitCodeTimeout        = 8
+	// exitCodeInterrupted follows shell convention of 128 + SIGINT
+	exitCodeInterrupted = 130
+)
+
+// exitCodeNames are stable identifiers of exit codes used in JSON error report.
+var exitCodeNames = map[int]string{
+	exitCodeError:          "error",
+	exitCodeUsage:          "usage",
+	exitCodeAuth:           "auth",
+	exitCodeNoDevice:       "no_device",
+	exitCodeDeviceOffline:  "device_offline",
+	exitCodeNotFound:       "not_found",
+	exitCodePartialFailure: "partial_failure",
+	exitCodeTimeout:        "timeout",
+	exitCodeInterrupted:    "interrupted",
+}
+
+// errorReport is machine-readable form of error, it is printed instead of plain message when JSON output is requested.
+type errorReport struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// exitError is error which should terminate program with specific exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns process exit code associated with given error, if there is any.
+func ExitCode(err error) (int, bool) {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code, true
+	}
+	return 0, false
+}
+
+// classifyError returns exit code associated with error, or derives it from kind of API failure.
+func classifyError(err error) int {
+	if code, ok := ExitCode(err); ok {
+		return code
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitCodeInterrupted
+	case isAuthError(err):
+		return exitCodeAuth
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return exitCodeTimeout
+	case containsApiError(err, []string{"OFFLINE"}):
+		return exitCodeDeviceOffline
+	}
+	return exitCodeError
+}
+
+// reportError prints error as single line, or as errorReport in case of JSON output.
+func reportError(w io.Writer, format outputFormat, cmd *cobra.Command, code int, err error) {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	msg := strings.Join(lines, " ")
+	if format == outputJson {
+		details := map[string]any{"exitCode": code}
+		if cmd != nil {
+			details["command"] = cmd.CommandPath()
+		}
+		data, _ := json.Marshal(errorReport{Code: exitCodeNames[code], Message: msg, Details: details})
+		fmt.Fprintf(w, "%s\n", data)
+		return
+	}
+	if code == exitCodeUsage && cmd != nil {
+		msg += fmt.Sprintf(" (see '%s --help')", cmd.CommandPath())
+	}
+	fmt.Fprintf(w, "Error: %s\n", msg)
+}
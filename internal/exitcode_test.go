@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{errors.New("plain"), exitCodeError},
+		{withExitCode(exitCodeNotFound, errors.New("none")), exitCodeNotFound},
+		{fmt.Errorf("wait: %w", withExitCode(exitCodeTimeout, os.ErrExist)), exitCodeTimeout},
+		{errors.New("api error: AUTH_FAILED"), exitCodeAuth},
+		{errors.New("device is OFFLINE"), exitCodeDeviceOffline},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), exitCodeTimeout},
+		{context.Canceled, exitCodeInterrupted},
+	} {
+		assert.Equal(t, tc.code, classifyError(tc.err), tc.err.Error())
+	}
+}
+
+func TestReportError(t *testing.T) {
+	var buf bytes.Buffer
+	reportError(&buf, "", nil, exitCodeError, errors.New("first line\n\n  second line\n"))
+	assert.Equal(t, "Error: first line second line\n", buf.String())
+
+	buf.Reset()
+	reportError(&buf, outputJson, nil, exitCodeAuth, errors.New("AUTH_FAILED"))
+	var report errorReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, errorReport{Code: "auth", Message: "AUTH_FAILED", Details: map[string]any{"exitCode": float64(3)}}, report)
+}
+
+func TestExecuteExitCodes(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	run := func(ctx context.Context, args ...string) (int, string) {
+		var out, errOut bytes.Buffer
+		code := Execute(ctx, args, strings.NewReader(""), &out, &errOut)
+		return code, errOut.String()
+	}
+
+	code, msg := run(context.Background(), "version")
+	assert.Equal(t, 0, code)
+	assert.Empty(t, msg)
+
+	code, msg = run(context.Background(), "download", "status", "--bogus")
+	assert.Equal(t, exitCodeUsage, code)
+	assert.Equal(t, "Error: unknown flag: --bogus (see 'jdcli download status --help')\n", msg)
+
+	code, msg = run(context.Background(), "config", "use-context", "missing", "-o", "json")
+	assert.Equal(t, exitCodeNotFound, code)
+	var report errorReport
+	assert.NoError(t, json.Unmarshal([]byte(msg), &report))
+	assert.Equal(t, "not_found", report.Code)
+	assert.Equal(t, "jdcli config use-context", report.Details["command"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	code, msg = run(ctx, "version")
+	assert.Equal(t, exitCodeInterrupted, code)
+	assert.Equal(t, "Error: interrupted\n", msg)
+}
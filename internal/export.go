@@ -0,0 +1,159 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportFormatDlc  = "dlc"
+	exportFormatTxt  = "txt"
+	exportFormatJson = "json"
+)
+
+var (
+	exportFormats = []string{exportFormatDlc, exportFormatTxt, exportFormatJson}
+	// containerTypes are container file extensions which are uploaded to LinkCollector as they are
+	containerTypes = []string{"dlc", "ccf", "rsdf"}
+)
+
+// exportPackage is download package together with its links, as written by json export.
+type exportPackage struct {
+	jdownloader.FilePackage
+	Links []jdownloader.DownloadLink `json:"links"`
+}
+
+func newDownloadExportCommand(out io.Writer) *cobra.Command {
+	type exportData struct {
+		format   string
+		packages []string
+		name     string
+		file     string
+	}
+	var data exportData
+	data.format = exportFormatTxt
+	c := &cobra.Command{
+		Use:   "export",
+		Short: "Export links of download packages as DLC container, plain list of URLs or JSON",
+		Long: `Export links of download packages (all packages when none is selected) as DLC container,
+plain list of URLs (one per line) or JSON. DLC container is created by JDownloader.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(exportFormats, data.format) {
+				return fmt.Errorf("unsupported format '%s', must be one of: %s", data.format, strings.Join(exportFormats, "|"))
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				pkgs, err := dl.Packages()
+				if err != nil {
+					return err
+				}
+				selected, err := selectExportPackages(*pkgs, data.packages, data.name)
+				if err != nil {
+					return err
+				}
+				if len(selected) == 0 {
+					return withExitCode(exitCodeNotFound, errors.New("no matching packages"))
+				}
+				var content []byte
+				if data.format == exportFormatDlc {
+					ids := make([]int64, 0, len(selected))
+					for _, pkg := range selected {
+						ids = append(ids, *pkg.Uuid)
+					}
+					if content, err = dl.ExportDLC([]int64{}, ids); err != nil {
+						return err
+					}
+				} else {
+					links, err := dl.Links()
+					if err != nil {
+						return err
+					}
+					var sb strings.Builder
+					if err = writeExport(&sb, data.format, exportPackages(selected, *links)); err != nil {
+						return err
+					}
+					content = []byte(sb.String())
+				}
+				if len(data.file) == 0 {
+					_, err = out.Write(content)
+					return err
+				}
+				if err = os.WriteFile(data.file, content, 0o644); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%d packages exported to %s\n", len(selected), data.file)
+				return nil
+			})
+		},
+	}
+	c.Flags().StringVar(&data.format, "format", data.format, fmt.Sprintf("Export format. One of: %s", strings.Join(exportFormats, "|")))
+	c.Flags().StringArrayVar(&data.packages, "package", data.packages, "Package UUID or name to export. Can be specified multiple times")
+	c.Flags().StringVar(&data.name, "name", data.name, "Select packages with name matching regular expression")
+	c.Flags().StringVarP(&data.file, "file", "f", data.file, "File to write export into, standard output is used when omitted")
+	return c
+}
+
+// exportPackages pairs packages with their links.
+func exportPackages(pkgs []jdownloader.FilePackage, links []jdownloader.DownloadLink) []exportPackage {
+	res := make([]exportPackage, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		ep := exportPackage{FilePackage: pkg, Links: make([]jdownloader.DownloadLink, 0)}
+		for _, link := range links {
+			if pkg.Uuid != nil && link.PackageUuid != nil && *link.PackageUuid == *pkg.Uuid {
+				ep.Links = append(ep.Links, link)
+			}
+		}
+		res = append(res, ep)
+	}
+	return res
+}
+
+func writeExport(w io.Writer, format string, pkgs []exportPackage) error {
+	if format == exportFormatJson {
+		return printJson(w, pkgs)
+	}
+	for _, pkg := range pkgs {
+		for _, link := range pkg.Links {
+			if link.Url == nil {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, *link.Url); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// containerType returns type of container file based on its extension, empty string for plain text files.
+func containerType(file string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	if slices.Contains(containerTypes, ext) {
+		return ext
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteExport(t *testing.T) {
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("movies")}, {Uuid: pint64(20), Name: pstr("music")}}
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Url: pstr("https://example.com/a.mkv")},
+		{Uuid: pint64(2), PackageUuid: pint64(20), Url: pstr("https://example.com/b.mp3")},
+		{Uuid: pint64(3), PackageUuid: pint64(10)},
+	}
+	selected, err := selectExportPackages(pkgs, []string{"movies"}, "")
+	assert.NoError(t, err)
+	exported := exportPackages(selected, links)
+	assert.Len(t, exported, 1)
+	assert.Len(t, exported[0].Links, 2)
+
+	var out bytes.Buffer
+	assert.NoError(t, writeExport(&out, exportFormatTxt, exported))
+	assert.Equal(t, "https://example.com/a.mkv\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, writeExport(&out, exportFormatJson, exported))
+	var parsed []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &parsed))
+	assert.Equal(t, "movies", parsed[0]["name"])
+	assert.Len(t, parsed[0]["links"], 2)
+}
+
+func TestContainerType(t *testing.T) {
+	assert.Equal(t, "dlc", containerType("shared/Movies.DLC"))
+	assert.Equal(t, "rsdf", containerType("a.rsdf"))
+	assert.Equal(t, "", containerType("links.txt"))
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const metricsNamespace = "jdownloader"
+
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type metricFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []string
+}
+
+// metricSet renders metrics in Prometheus text exposition format, samples are grouped by family.
+type metricSet struct {
+	families []*metricFamily
+	index    map[string]*metricFamily
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{index: make(map[string]*metricFamily)}
+}
+
+// add appends sample to metric family, labels are given as name/value pairs.
+func (ms *metricSet) add(name, typ, help string, value float64, labels ...string) {
+	name = metricsNamespace + "_" + name
+	mf, ok := ms.index[name]
+	if !ok {
+		mf = &metricFamily{name: name, typ: typ, help: help}
+		ms.index[name] = mf
+		ms.families = append(ms.families, mf)
+	}
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			fmt.Fprintf(&sb, `%s="%s"`, labels[i], metricsLabelEscaper.Replace(labels[i+1]))
+		}
+		sb.WriteString("}")
+	}
+	sb.WriteString(" ")
+	sb.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	mf.samples = append(mf.samples, sb.String())
+}
+
+func (ms *metricSet) write(w io.Writer) error {
+	for _, mf := range ms.families {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s\n",
+			mf.name, mf.help, mf.name, mf.typ, strings.Join(mf.samples, "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type exporter struct {
+	ctx     context.Context
+	client  jdownloader.JdClient
+	devices []string
+	ttl     time.Duration
+	timeout time.Duration
+	logger  *slog.Logger
+	// mu guards cached metrics and running scrape, it is never held during API calls
+	mu       sync.Mutex
+	cached   []byte
+	cachedAt time.Time
+	running  *scrapeCall
+	// errors and listErrors are only accessed by single running scrape
+	errors map[string]float64
+	// listErrors counts failures to list devices, when no explicit device was configured
+	listErrors float64
+}
+
+// scrapeCall is scrape in progress, concurrent requests wait for its result instead of querying devices again.
+type scrapeCall struct {
+	done chan struct{}
+	res  []byte
+}
+
+func newExporterCommand(out io.Writer) *cobra.Command {
+	type exporterData struct {
+		listen        string
+		devices       []string
+		cacheTtl      time.Duration
+		scrapeTimeout time.Duration
+	}
+	var data exporterData
+	data.listen = ":9798"
+	data.cacheTtl = 15 * time.Second
+	data.scrapeTimeout = 10 * time.Second
+	c := &cobra.Command{
+		Use:   "exporter",
+		Short: "Expose metrics of devices in Prometheus format",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc := cliContextOf(cmd)
+			client, _, err := cc.getClient()
+			if err != nil {
+				return err
+			}
+			e := &exporter{
+				ctx:     cmd.Context(),
+				client:  client,
+				devices: data.devices,
+				ttl:     data.cacheTtl,
+				timeout: data.scrapeTimeout,
+				logger:  cc.getLogger(),
+				errors:  make(map[string]float64),
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", e)
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `<html><body><h1>jdcli exporter</h1><a href="/metrics">Metrics</a></body></html>`)
+			})
+			fmt.Fprintf(out, "Listening on %s\n", data.listen)
+			srv := &http.Server{Addr: data.listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-cmd.Context().Done()
+				_ = srv.Close()
+			}()
+			if err = srv.ListenAndServe(); errors.Is(err, http.ErrServerClosed) {
+				return cmd.Context().Err()
+			}
+			return err
+		},
+	}
+	c.Flags().StringVar(&data.listen, "listen", data.listen, "Address to listen on")
+	c.Flags().StringArrayVar(&data.devices, "device", data.devices, "Device to collect metrics from. Can be specified multiple times, all devices are used when omitted")
+	c.Flags().DurationVar(&data.cacheTtl, "cache-ttl", data.cacheTtl, "How long to serve collected metrics before querying devices again")
+	c.Flags().DurationVar(&data.scrapeTimeout, "scrape-timeout", data.scrapeTimeout, "Maximum duration of querying devices, including retries of failed API calls")
+	return c
+}
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(e.scrape(r.Context()))
+}
+
+// scrape returns cached metrics while they are fresh, otherwise it collects them again or waits for collection
+// which is already running. Request cancelled while waiting gets last cached metrics.
+func (e *exporter) scrape(ctx context.Context) []byte {
+	e.mu.Lock()
+	if e.cached != nil && time.Since(e.cachedAt) < e.ttl {
+		defer e.mu.Unlock()
+		return e.cached
+	}
+	call := e.running
+	if call == nil {
+		call = &scrapeCall{done: make(chan struct{})}
+		e.running = call
+		go e.collect(call)
+	}
+	e.mu.Unlock()
+	select {
+	case <-call.done:
+		return call.res
+	case <-ctx.Done():
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.cached
+	}
+}
+
+// collect queries devices within scrape timeout, independently of requests which wait for it.
+func (e *exporter) collect(call *scrapeCall) {
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+	defer cancel()
+	client := e.client
+	if rc, ok := client.(*retryingClient); ok {
+		client = rc.withContext(ctx)
+	}
+	ms := newMetricSet()
+	devices := e.devices
+	if len(devices) == 0 {
+		devs, err := client.ListDevices()
+		if err != nil {
+			e.logger.Warn("unable to list devices", "error", err)
+			e.listErrors++
+		} else {
+			for _, dev := range *devs {
+				devices = append(devices, dev.Name)
+			}
+		}
+	}
+	for _, name := range devices {
+		start := time.Now()
+		err := collectDevice(ms, client, name)
+		up := 1.0
+		if err != nil {
+			e.logger.Warn("unable to collect metrics", "device", name, "error", err)
+			e.errors[name]++
+			up = 0
+		}
+		ms.add("up", "gauge", "Whether last scrape of device was successful.", up, "device", name)
+		ms.add("scrape_duration_seconds", "gauge", "Duration of device scrape.", time.Since(start).Seconds(), "device", name)
+	}
+	names := make([]string, 0, len(e.errors))
+	for name := range e.errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ms.add("scrape_errors_total", "counter", "Number of failed device scrapes.", e.errors[name], "device", name)
+	}
+	if len(e.devices) == 0 {
+		ms.add("device_list_errors_total", "counter", "Number of failures to list devices.", e.listErrors)
+	}
+	var buf bytes.Buffer
+	_ = ms.write(&buf)
+	e.mu.Lock()
+	e.cached, e.cachedAt, e.running = buf.Bytes(), time.Now(), nil
+	call.res = e.cached
+	e.mu.Unlock()
+	close(call.done)
+}
+
+func collectDevice(ms *metricSet, client jdownloader.JdClient, name string) error {
+	dev, err := client.Device(name)
+	if err != nil {
+		return err
+	}
+	dl := dev.Downloader()
+	si, err := dl.Speed()
+	if err != nil {
+		return err
+	}
+	st, err := dl.State()
+	if err != nil {
+		return err
+	}
+	links, err := dl.Links()
+	if err != nil {
+		return err
+	}
+	pkgs, err := dl.Packages()
+	if err != nil {
+		return err
+	}
+	collected, err := dev.LinkGrabber().Links()
+	if err != nil {
+		return err
+	}
+	collectDeviceMetrics(ms, name, floatVal(si.Speed), strVal(st.State), *links, *pkgs, len(*collected))
+	return nil
+}
+
+func collectDeviceMetrics(ms *metricSet, device string, speed float64, state string,
+	links []jdownloader.DownloadLink, pkgs []jdownloader.FilePackage, collected int) {
+	ms.add("download_speed_bytes", "gauge", "Current download speed in bytes per second.", speed, "device", device)
+	ms.add("state", "gauge", "Current state of downloader.", 1, "device", device, "state", state)
+
+	linkCounts := map[string]int{"finished": 0, "failed": 0, "running": 0, "disabled": 0, "queued": 0}
+	for _, link := range links {
+		linkCounts[linkStatus(link)]++
+	}
+	for _, status := range []string{"finished", "failed", "running", "disabled", "queued"} {
+		ms.add("links", "gauge", "Number of download links by status.", float64(linkCounts[status]),
+			"device", device, "status", status)
+	}
+
+	pkgCounts := map[string]int{"finished": 0, "running": 0, "queued": 0}
+	for _, pkg := range pkgs {
+		switch {
+		case pkg.Finished != nil && *pkg.Finished:
+			pkgCounts["finished"]++
+		case pkg.Running != nil && *pkg.Running:
+			pkgCounts["running"]++
+		default:
+			pkgCounts["queued"]++
+		}
+	}
+	for _, status := range []string{"finished", "running", "queued"} {
+		ms.add("packages", "gauge", "Number of download packages by status.", float64(pkgCounts[status]),
+			"device", device, "status", status)
+	}
+	for _, pkg := range pkgs {
+		ms.add("package_bytes_loaded", "gauge", "Bytes loaded of download package.", float64(intVal(pkg.BytesLoaded)),
+			"device", device, "package", strVal(pkg.Name), "uuid", idVal(pkg.Uuid))
+	}
+	for _, pkg := range pkgs {
+		ms.add("package_bytes_total", "gauge", "Total bytes of download package.", float64(intVal(pkg.BytesTotal)),
+			"device", device, "package", strVal(pkg.Name), "uuid", idVal(pkg.Uuid))
+	}
+	ms.add("linkgrabber_links", "gauge", "Number of links in link collector.", float64(collected), "device", device)
+}
+
+// linkStatus classifies link using structured fields, so that it does not depend on localized status text.
+func linkStatus(link jdownloader.DownloadLink) string {
+	switch {
+	case isLinkFinished(link):
+		return "finished"
+	case isLinkFailed(link):
+		return "failed"
+	case link.Running != nil && *link.Running:
+		return "running"
+	case link.Enabled != nil && !*link.Enabled:
+		return "disabled"
+	default:
+		return "queued"
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectDeviceMetrics(t *testing.T) {
+	ms := newMetricSet()
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), Finished: pbool(true)},
+		{Uuid: pint64(2), Running: pbool(true)},
+		{Uuid: pint64(3), Enabled: pbool(false)},
+	}
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr(`my "pkg"`), BytesLoaded: pint64(5), BytesTotal: pint64(10)}}
+	collectDeviceMetrics(ms, "nas", 1024, "RUNNING", links, pkgs, 3)
+	var buf bytes.Buffer
+	assert.NoError(t, ms.write(&buf))
+	res := buf.String()
+	assert.Contains(t, res, "# TYPE jdownloader_download_speed_bytes gauge\njdownloader_download_speed_bytes{device=\"nas\"} 1024\n")
+	assert.Contains(t, res, `jdownloader_links{device="nas",status="finished"} 1`)
+	assert.Contains(t, res, `jdownloader_links{device="nas",status="disabled"} 1`)
+	assert.Contains(t, res, `jdownloader_packages{device="nas",status="queued"} 1`)
+	assert.Contains(t, res, `jdownloader_package_bytes_loaded{device="nas",package="my \"pkg\"",uuid="10"} 5`)
+	assert.Contains(t, res, `jdownloader_linkgrabber_links{device="nas"} 3`)
+}
+
+func TestExporterScrapeErrors(t *testing.T) {
+	e := &exporter{
+		ctx:     context.Background(),
+		client:  jdownloader.NewMockClient(),
+		devices: []string{"nas"},
+		ttl:     time.Minute,
+		timeout: time.Second,
+		errors:  make(map[string]float64),
+		logger:  newCliContext(nil, nil, nil).getLogger(),
+	}
+	res := string(e.scrape(context.Background()))
+	assert.Contains(t, res, `jdownloader_up{device="nas"} 0`)
+	assert.Contains(t, res, `jdownloader_scrape_errors_total{device="nas"} 1`)
+}
+
+type blockingClient struct {
+	jdownloader.JdClient
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (b *blockingClient) Device(string) (jdownloader.Device, error) {
+	b.calls.Add(1)
+	<-b.release
+	return nil, errors.New("device not found")
+}
+
+func TestExporterScrapeDoesNotBlock(t *testing.T) {
+	client := &blockingClient{release: make(chan struct{})}
+	e := &exporter{
+		ctx:     context.Background(),
+		client:  client,
+		devices: []string{"nas"},
+		ttl:     time.Minute,
+		timeout: time.Minute,
+		errors:  make(map[string]float64),
+		logger:  newCliContext(nil, nil, nil).getLogger(),
+		cached:  []byte("stale"),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, "stale", string(e.scrape(ctx)))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, "stale", string(e.scrape(ctx)))
+	assert.Equal(t, int32(1), client.calls.Load())
+
+	close(client.release)
+	res := string(e.scrape(context.Background()))
+	assert.Contains(t, res, `jdownloader_up{device="nas"} 0`)
+	assert.Equal(t, res, string(e.scrape(context.Background())))
+	assert.Equal(t, int32(1), client.calls.Load())
+}
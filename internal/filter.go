@@ -0,0 +1,401 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/pflag"
+)
+
+const filterHelp = `Filter expression, e.g. "status=Finished", "host~rapidgator && size>1GiB" or "name=~'.*\.mkv' || package=123".
+Operators: = != (equality), ~ !~ (contains), =~ (regular expression), > >= < <= (numbers and sizes).
+Conditions are combined by && (or "," and "and") and || (or "or"), grouped by parentheses and negated by !`
+
+// filterOps are ordered so that longer operators are matched first
+var filterOps = []string{"=~", "!~", "!=", "==", ">=", "<=", "=", "~", ">", "<"}
+
+// filterAliases map convenient names to JSON keys, first key present in listed type is used
+var filterAliases = map[string][]string{
+	"size":    {"bytesTotal"},
+	"loaded":  {"bytesLoaded"},
+	"id":      {"uuid"},
+	"package": {"packageUUID", "uuid"},
+}
+
+var sizeRe = regexp.MustCompile(`(?i)^([0-9]*\.?[0-9]+)\s*(b|[kmgtp]i?b?)?$`)
+
+type filterExpr interface {
+	match(obj map[string]interface{}) bool
+}
+
+type filterAnd []filterExpr
+
+func (f filterAnd) match(obj map[string]interface{}) bool {
+	for _, e := range f {
+		if !e.match(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+type filterOr []filterExpr
+
+func (f filterOr) match(obj map[string]interface{}) bool {
+	for _, e := range f {
+		if e.match(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+type filterNot struct {
+	expr filterExpr
+}
+
+func (f filterNot) match(obj map[string]interface{}) bool {
+	return !f.expr.match(obj)
+}
+
+type filterCond struct {
+	field string
+	op    string
+	value string
+	num   *float64
+	re    *regexp.Regexp
+}
+
+func (f *filterCond) match(obj map[string]interface{}) bool {
+	v, present := obj[f.field]
+	var str string
+	if present && v != nil {
+		str = fmt.Sprint(v)
+	}
+	switch f.op {
+	case "~":
+		return strings.Contains(strings.ToLower(str), strings.ToLower(f.value))
+	case "!~":
+		return !strings.Contains(strings.ToLower(str), strings.ToLower(f.value))
+	case "=~":
+		return f.re.MatchString(str)
+	case "=", "==":
+		return f.equal(v, str)
+	case "!=":
+		return !f.equal(v, str)
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return false
+	}
+	fv, err := n.Float64()
+	if err != nil {
+		return false
+	}
+	switch f.op {
+	case ">":
+		return fv > *f.num
+	case ">=":
+		return fv >= *f.num
+	case "<":
+		return fv < *f.num
+	default:
+		return fv <= *f.num
+	}
+}
+
+func (f *filterCond) equal(v interface{}, str string) bool {
+	if n, ok := v.(json.Number); ok && f.num != nil {
+		if fv, err := n.Float64(); err == nil {
+			return fv == *f.num
+		}
+	}
+	return strings.EqualFold(str, f.value)
+}
+
+// parseSize parses plain number or size with decimal (KB, MB, ...) or binary (KiB, MiB, ...) unit.
+func parseSize(s string) (float64, error) {
+	m := sizeRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid number or size: '%s'", s)
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	unit := strings.ToLower(m[2])
+	if len(unit) == 0 || unit == "b" {
+		return v, nil
+	}
+	base := 1000.0
+	if strings.Contains(unit, "i") {
+		base = 1024
+	}
+	return v * math.Pow(base, float64(strings.IndexByte("kmgtp", unit[0])+1)), nil
+}
+
+// filterFields returns JSON keys of struct fields indexed by lower-cased name.
+func filterFields(t reflect.Type) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if tag, ok := t.Field(i).Tag.Lookup("json"); ok {
+			name, _, _ = strings.Cut(tag, ",")
+		}
+		if name != "-" {
+			fields[strings.ToLower(name)] = name
+		}
+	}
+	for alias, keys := range filterAliases {
+		if _, ok := fields[alias]; ok {
+			continue
+		}
+		for _, key := range keys {
+			if field, ok := fields[strings.ToLower(key)]; ok {
+				fields[alias] = field
+				break
+			}
+		}
+	}
+	return fields
+}
+
+type filterParser struct {
+	input  []rune
+	pos    int
+	fields map[string]string
+}
+
+// parseFilter parses filter expression for items of type T, field names are validated against JSON keys of T.
+func parseFilter[T any](expr string) (filterExpr, error) {
+	p := &filterParser{input: []rune(expr), fields: filterFields(reflect.TypeFor[T]())}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected '%s' at position %d of filter", string(p.input[p.pos:]), p.pos)
+	}
+	return e, nil
+}
+
+func (p *filterParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// accept consumes one of tokens, words are matched case-insensitively and must be followed by space or parenthesis.
+func (p *filterParser) accept(tokens ...string) bool {
+	p.skipSpace()
+	rest := string(p.input[p.pos:])
+	for _, tok := range tokens {
+		if len(rest) < len(tok) || !strings.EqualFold(rest[:len(tok)], tok) {
+			continue
+		}
+		if unicode.IsLetter([]rune(tok)[0]) {
+			next := []rune(rest[len(tok):])
+			if len(next) == 0 || !(unicode.IsSpace(next[0]) || next[0] == '(') {
+				continue
+			}
+		}
+		p.pos += len([]rune(tok))
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	var res filterOr
+	for {
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+		if !p.accept("||", "or") {
+			break
+		}
+	}
+	if len(res) == 1 {
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	var res filterAnd
+	for {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+		if !p.accept("&&", ",", "and") {
+			break
+		}
+	}
+	if len(res) == 1 {
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.accept("!") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{e}, nil
+	}
+	if p.accept("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')' at position %d of filter", p.pos)
+		}
+		return e, nil
+	}
+	return p.parseCond()
+}
+
+func (p *filterParser) parseCond() (filterExpr, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsLetter(p.input[p.pos]) || unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	name := string(p.input[start:p.pos])
+	if len(name) == 0 {
+		return nil, fmt.Errorf("expected field name at position %d of filter", start)
+	}
+	field, ok := p.fields[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown field '%s' in filter", name)
+	}
+	cond := &filterCond{field: field}
+	p.skipSpace()
+	rest := string(p.input[p.pos:])
+	for _, op := range filterOps {
+		if strings.HasPrefix(rest, op) {
+			cond.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if len(cond.op) == 0 {
+		return nil, fmt.Errorf("expected operator after '%s' in filter", name)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	cond.value = value
+	switch cond.op {
+	case "=~":
+		if cond.re, err = regexp.Compile("^(?:" + value + ")$"); err != nil {
+			return nil, err
+		}
+	case ">", ">=", "<", "<=":
+		n, err := parseSize(value)
+		if err != nil {
+			return nil, err
+		}
+		cond.num = &n
+	case "=", "==", "!=":
+		if n, err := parseSize(value); err == nil {
+			cond.num = &n
+		}
+	}
+	return cond, nil
+}
+
+// parseValue reads quoted value, or unquoted value which ends at space, comma, parenthesis or logical operator.
+func (p *filterParser) parseValue() (string, error) {
+	p.skipSpace()
+	if p.pos < len(p.input) && (p.input[p.pos] == '\'' || p.input[p.pos] == '"') {
+		quote := p.input[p.pos]
+		var sb bytes.Buffer
+		for p.pos++; p.pos < len(p.input); p.pos++ {
+			switch r := p.input[p.pos]; {
+			case r == '\\' && p.pos+1 < len(p.input) && p.input[p.pos+1] == quote:
+				sb.WriteRune(quote)
+				p.pos++
+			case r == quote:
+				p.pos++
+				return sb.String(), nil
+			default:
+				sb.WriteRune(r)
+			}
+		}
+		return "", fmt.Errorf("unterminated string in filter")
+	}
+	start := p.pos
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		if unicode.IsSpace(r) || r == ',' || r == ')' || r == '(' ||
+			strings.HasPrefix(string(p.input[p.pos:]), "&&") || strings.HasPrefix(string(p.input[p.pos:]), "||") {
+			break
+		}
+		p.pos++
+	}
+	return string(p.input[start:p.pos]), nil
+}
+
+// filterList returns items which match filter expression, all items are returned when expression is empty.
+func filterList[T any](items []T, expr string) ([]T, error) {
+	if len(strings.TrimSpace(expr)) == 0 {
+		return items, nil
+	}
+	f, err := parseFilter[T](expr)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		obj := make(map[string]interface{})
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err = dec.Decode(&obj); err != nil {
+			return nil, err
+		}
+		if f.match(obj) {
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func addFilterFlag(fs *pflag.FlagSet, target *string) {
+	fs.StringVar(target, "filter", *target, filterHelp)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFilterLinks = []jdownloader.DownloadLink{
+	{Uuid: pint64(1), PackageUuid: pint64(10), Name: pstr("movie.mkv"), Host: pstr("rapidgator.net"),
+		Status: pstr("Finished"), BytesTotal: pint64(2 << 30), Finished: pbool(true)},
+	{Uuid: pint64(2), PackageUuid: pint64(10), Name: pstr("movie.mkv.part2"), Host: pstr("mega.nz"),
+		Status: pstr("Running"), BytesTotal: pint64(512 << 20)},
+	{Uuid: pint64(3), PackageUuid: pint64(20), Name: pstr("notes.txt"), Host: pstr("RapidGator.net"),
+		BytesTotal: pint64(1000)},
+}
+
+func filteredIds(t *testing.T, expr string) []int64 {
+	items, err := filterList(testFilterLinks, expr)
+	assert.NoError(t, err)
+	ids := make([]int64, 0)
+	for _, item := range items {
+		ids = append(ids, *item.Uuid)
+	}
+	return ids
+}
+
+func TestFilterList(t *testing.T) {
+	assert.Equal(t, []int64{1, 2, 3}, filteredIds(t, ""))
+	assert.Equal(t, []int64{1}, filteredIds(t, "status=finished"))
+	assert.Equal(t, []int64{2, 3}, filteredIds(t, "status!=Finished"))
+	assert.Equal(t, []int64{1, 3}, filteredIds(t, "host~rapidgator"))
+	assert.Equal(t, []int64{2}, filteredIds(t, "host!~rapidgator"))
+	assert.Equal(t, []int64{1}, filteredIds(t, "size>1GiB"))
+	assert.Equal(t, []int64{2, 3}, filteredIds(t, "size <= 1GB"))
+	assert.Equal(t, []int64{1}, filteredIds(t, `name=~'.*\.mkv'`))
+	assert.Equal(t, []int64{3}, filteredIds(t, "package=20"))
+	assert.Equal(t, []int64{3}, filteredIds(t, "host~rapidgator && size<1KiB"))
+	assert.Equal(t, []int64{3}, filteredIds(t, "host~rapidgator, !finished=true"))
+	assert.Equal(t, []int64{1, 2}, filteredIds(t, "status=Finished || host=mega.nz"))
+	assert.Equal(t, []int64{2, 3}, filteredIds(t, "(package=10 and host~mega) or name='notes.txt'"))
+	assert.Equal(t, []int64{1, 2}, filteredIds(t, "PackageUUID=10"))
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		"unknown=1",
+		"status",
+		"size>big",
+		"(status=Finished",
+		"name='abc",
+		"name=~'('",
+		"status=Finished)",
+	} {
+		_, err := filterList(testFilterLinks, expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestFilterPackages(t *testing.T) {
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("a")}, {Uuid: pint64(20), Name: pstr("b")}}
+	items, err := filterList(pkgs, "package=20")
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "b", *items[0].Name)
+}
+
+func TestParseSize(t *testing.T) {
+	for in, exp := range map[string]float64{"1024": 1024, "1KiB": 1024, "1kb": 1000, "1.5 GiB": 1.5 * (1 << 30), "2M": 2e6, "3B": 3} {
+		v, err := parseSize(in)
+		assert.NoError(t, err)
+		assert.Equal(t, exp, v, in)
+	}
+}
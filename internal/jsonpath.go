@@ -0,0 +1,276 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a small subset of kubectl's JSONPath templates.
+// Supported are plain text, quoted literals ({"\n"}), field access (.name, ['name']),
+// wildcards (.*, [*]), indexes ([0], [-1]) and {range <path>}...{end} blocks.
+type jsonPath struct {
+	nodes []jpNode
+}
+
+type jpNode struct {
+	text  *string
+	path  []jpStep
+	block []jpNode
+}
+
+type jpStep struct {
+	field    *string
+	index    *int
+	wildcard bool
+}
+
+func parseJsonPath(tpl string) (*jsonPath, error) {
+	nodes, _, err := parseJpNodes(tpl, false)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonPath{nodes: nodes}, nil
+}
+
+func parseJpNodes(tpl string, inRange bool) ([]jpNode, string, error) {
+	nodes := make([]jpNode, 0)
+	for len(tpl) > 0 {
+		start := strings.IndexByte(tpl, '{')
+		if start == -1 {
+			text := tpl
+			nodes = append(nodes, jpNode{text: &text})
+			break
+		}
+		if start > 0 {
+			text := tpl[:start]
+			nodes = append(nodes, jpNode{text: &text})
+		}
+		end := strings.IndexByte(tpl[start:], '}')
+		if end == -1 {
+			return nil, "", fmt.Errorf("unclosed action in jsonpath template: %s", tpl[start:])
+		}
+		expr := strings.TrimSpace(tpl[start+1 : start+end])
+		tpl = tpl[start+end+1:]
+		switch {
+		case expr == "end":
+			if !inRange {
+				return nil, "", errors.New("unexpected {end} in jsonpath template")
+			}
+			return nodes, tpl, nil
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseJpPath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, "", err
+			}
+			inner, rest, err := parseJpNodes(tpl, true)
+			if err != nil {
+				return nil, "", err
+			}
+			if inner == nil {
+				return nil, "", errors.New("missing {end} for {range} in jsonpath template")
+			}
+			nodes = append(nodes, jpNode{path: path, block: inner})
+			tpl = rest
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			if len(expr) < 2 {
+				return nil, "", fmt.Errorf("invalid literal in jsonpath template: %s", expr)
+			}
+			if expr[0] == '\'' {
+				expr = `"` + strings.ReplaceAll(expr[1:len(expr)-1], `"`, `\"`) + `"`
+			}
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid literal in jsonpath template: %s", expr)
+			}
+			nodes = append(nodes, jpNode{text: &text})
+		default:
+			path, err := parseJpPath(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jpNode{path: path})
+		}
+	}
+	if inRange {
+		return nil, "", nil
+	}
+	return nodes, "", nil
+}
+
+func parseJpPath(expr string) ([]jpStep, error) {
+	steps := make([]jpStep, 0)
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), "@")
+	if len(expr) > 0 && expr[0] != '.' && expr[0] != '[' {
+		expr = "." + expr
+	}
+	for len(expr) > 0 {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			n := strings.IndexAny(expr, ".[")
+			if n == -1 {
+				n = len(expr)
+			}
+			name := expr[:n]
+			expr = expr[n:]
+			if name == "*" {
+				steps = append(steps, jpStep{wildcard: true})
+			} else if len(name) > 0 {
+				steps = append(steps, jpStep{field: &name})
+			}
+		case '[':
+			n := strings.IndexByte(expr, ']')
+			if n == -1 {
+				return nil, fmt.Errorf("unclosed bracket in jsonpath expression: %s", expr)
+			}
+			sel := strings.TrimSpace(expr[1:n])
+			expr = expr[n+1:]
+			switch {
+			case sel == "*":
+				steps = append(steps, jpStep{wildcard: true})
+			case len(sel) > 1 && (sel[0] == '\'' || sel[0] == '"'):
+				name := sel[1 : len(sel)-1]
+				steps = append(steps, jpStep{field: &name})
+			default:
+				idx, err := strconv.Atoi(sel)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index in jsonpath expression: %s", sel)
+				}
+				steps = append(steps, jpStep{index: &idx})
+			}
+		default:
+			return nil, fmt.Errorf("invalid jsonpath expression: %s", expr)
+		}
+	}
+	return steps, nil
+}
+
+func (jp *jsonPath) execute(out io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var root interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&root); err != nil {
+		return err
+	}
+	return executeJpNodes(out, jp.nodes, root)
+}
+
+func executeJpNodes(out io.Writer, nodes []jpNode, current interface{}) error {
+	for _, node := range nodes {
+		if node.text != nil {
+			if _, err := io.WriteString(out, *node.text); err != nil {
+				return err
+			}
+			continue
+		}
+		results, err := evalJpPath(node.path, current)
+		if err != nil {
+			return err
+		}
+		if node.block != nil {
+			for _, r := range results {
+				if err = executeJpNodes(out, node.block, r); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		parts := make([]string, len(results))
+		for i, r := range results {
+			if parts[i], err = formatJpValue(r); err != nil {
+				return err
+			}
+		}
+		if _, err = io.WriteString(out, strings.Join(parts, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func evalJpPath(steps []jpStep, current interface{}) ([]interface{}, error) {
+	results := []interface{}{current}
+	for _, step := range steps {
+		next := make([]interface{}, 0)
+		for _, r := range results {
+			switch val := r.(type) {
+			case map[string]interface{}:
+				if step.field != nil {
+					if fv, ok := val[*step.field]; ok {
+						next = append(next, fv)
+					}
+				} else if step.wildcard {
+					keys := make([]string, 0, len(val))
+					for k := range val {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, val[k])
+					}
+				} else {
+					return nil, fmt.Errorf("cannot index object with [%d]", *step.index)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, val...)
+				} else if step.index != nil {
+					idx := *step.index
+					if idx < 0 {
+						idx += len(val)
+					}
+					if idx < 0 || idx >= len(val) {
+						return nil, fmt.Errorf("array index out of bounds: %d", *step.index)
+					}
+					next = append(next, val[idx])
+				} else {
+					return nil, fmt.Errorf("cannot access field '%s' of array", *step.field)
+				}
+			}
+		}
+		results = next
+	}
+	return results, nil
+}
+
+func formatJpValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	default:
+		data, err := json.Marshal(val)
+		return string(data), err
+	}
+}
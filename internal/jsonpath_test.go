@@ -0,0 +1,61 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonPath(t *testing.T) {
+	uuid := int64(1700000000123)
+	name := "file.mkv"
+	links := []jdownloader.DownloadLink{{Uuid: &uuid, Name: &name}, {Uuid: pint64(2)}}
+	for tpl, expected := range map[string]string{
+		"{[*].name}":                          "file.mkv",
+		"{[*].uuid}":                          "1700000000123 2",
+		"{[0]['name']}":                       "file.mkv",
+		"{$[-1].uuid}":                        "2",
+		`{range [*]}{.uuid}{"\n"}{end}`:       "1700000000123\n2\n",
+		`uuids: {range [*]}[{.uuid}]{end}...`: "uuids: [1700000000123][2]...",
+	} {
+		var buf bytes.Buffer
+		assert.NoError(t, printList(printer{out: &buf, format: outputFormat(outputJsonPath + "=" + tpl)}, links, dlCols), tpl)
+		assert.Equal(t, expected, buf.String(), tpl)
+	}
+}
+
+func TestJsonPathInvalid(t *testing.T) {
+	for _, tpl := range []string{"{[*].name", "{range [*]}{.uuid}", "{end}", "{[x]}", `{"abc}`} {
+		_, err := parseJsonPath(tpl)
+		assert.Error(t, err, tpl)
+	}
+}
+
+func TestGoTemplate(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		f   outputFormat
+	)
+	assert.NoError(t, f.Set(`go-template={{range .}}{{.Uuid}}{{"\n"}}{{end}}`))
+	assert.NoError(t, printList(printer{out: &buf, format: f}, []jdownloader.DownloadLink{{Uuid: pint64(10)}, {Uuid: pint64(20)}}, dlCols))
+	assert.Equal(t, "10\n20\n", buf.String())
+	assert.Error(t, f.Set("go-template={{range .}"))
+}
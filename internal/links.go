@@ -17,22 +17,56 @@ limitations under the License.
 package internal
 
 import (
-	"encoding/json"
+	"bufio"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net/url"
 	"os"
-	"strconv"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/rkosegi/jdownloader-go/jdownloader"
 	"github.com/spf13/cobra"
 )
 
 var (
-	clCols = []string{"ID", "Name", "URL", "Status", "Size"}
+	linkSchemes    = []string{"http", "https", "ftp"}
+	embeddedLinkRe = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s"'<>]+`)
+
+	clCols = []column[jdownloader.CrawledLink]{
+		{name: "ID", value: func(l jdownloader.CrawledLink) string { return idVal(l.Uuid) },
+			key: func(l jdownloader.CrawledLink) any { return intVal(l.Uuid) }},
+		{name: "Name", value: func(l jdownloader.CrawledLink) string { return strVal(l.Name) }},
+		{name: "URL", value: func(l jdownloader.CrawledLink) string { return strVal(l.Url) }},
+		{name: "Host", wide: true, value: func(l jdownloader.CrawledLink) string { return strVal(l.Host) }},
+		{name: "Status", value: func(l jdownloader.CrawledLink) string { return strVal(l.Status) }},
+		{name: "Availability", wide: true, value: func(l jdownloader.CrawledLink) string { return strVal(l.Availability) }},
+		{name: "Size", value: func(l jdownloader.CrawledLink) string {
+			if l.BytesTotal == nil {
+				return ""
+			}
+			size := int64(*l.BytesTotal)
+			return formatSize(&size)
+		}, key: func(l jdownloader.CrawledLink) any {
+			if l.BytesTotal == nil {
+				return int64(0)
+			}
+			return int64(*l.BytesTotal)
+		}},
+		{name: "Package", wide: true, value: func(l jdownloader.CrawledLink) string { return idVal(l.PackageUuid) },
+			key: func(l jdownloader.CrawledLink) any { return intVal(l.PackageUuid) }},
+	}
+	clExtraCols = []column[jdownloader.CrawledLink]{
+		{name: "Enabled", value: func(l jdownloader.CrawledLink) string { return boolVal(l.Enabled) }},
+		{name: "Priority", value: func(l jdownloader.CrawledLink) string { return strVal(l.Priority) }},
+		{name: "Comment", value: func(l jdownloader.CrawledLink) string { return strVal(l.Comment) }},
+	}
 )
 
 func newLinksCommand(out io.Writer) *cobra.Command {
@@ -42,36 +76,106 @@ func newLinksCommand(out io.Writer) *cobra.Command {
 	}
 	c.AddCommand(newAddLinksCommand(out))
 	c.AddCommand(newListLinksCommand(out))
+	c.AddCommand(newConfirmLinksCommand(out))
+	c.AddCommand(newRmLinksCommand(out))
+	c.AddCommand(newClearLinksCommand(out))
+	c.AddCommand(newCleanupLinksCommand(out))
+	c.AddCommand(newImportCrawlJobCommand(out))
+	c.AddCommand(newListCollectorPackagesCommand(out, "packages"))
+	c.AddCommand(newLinksPackageCommand(out))
+	return c
+}
+
+func newLinksPackageCommand(out io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "package",
+		Short: "Manages link collector packages",
+	}
+	c.AddCommand(newListCollectorPackagesCommand(out, "list"))
+	addPackageCommands(c, out, collectorPackages)
 	return c
 }
 
 func newAddLinksCommand(out io.Writer) *cobra.Command {
 	type addData struct {
-		commonData
-		fromFile    string
+		fromFile    []string
+		clipboard   bool
 		links       []string
 		packageName string
 		downloadDir string
 		autoStart   bool
+		wait        crawlWaitOptions
 	}
 	var data addData
 	data.autoStart = false
+	data.wait.poll = 2 * time.Second
 	data.links = make([]string, 0)
 	c := &cobra.Command{
-		Use:   "add",
+		Use:   "add [URL...]",
 		Short: "Add one or more links to LinkCollector",
+		Long: fmt.Sprintf(`Add one or more links to LinkCollector.
+
+Links are given as arguments, by --link, read from files (--from-file, '-' for standard input) or from clipboard.
+Links embedded in text or HTML are extracted, lines starting with '#' or ';' are ignored. Only links with one
+of schemes %s are accepted, rejected lines are reported. Repeated links are sent only once.
+Container files (%s) given by --from-file are uploaded to LinkCollector as they are.`, strings.Join(linkSchemes, ", "), strings.Join(containerTypes, ", ")),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(data.fromFile) > 0 {
-				links, err := parseLinksFromFile(data.fromFile)
+			links := make([]string, 0)
+			rejected := make([]rejectedLine, 0)
+			for _, link := range append(data.links, args...) {
+				if validLink(link) {
+					links = append(links, link)
+				} else {
+					rejected = append(rejected, rejectedLine{source: "argument", text: link})
+				}
+			}
+			containers := make(map[string][]byte)
+			for _, file := range data.fromFile {
+				if containerType(file) != "" {
+					content, err := os.ReadFile(file)
+					if err != nil {
+						return err
+					}
+					containers[file] = content
+					continue
+				}
+				fileLinks, fileRejected, err := parseLinksFromFile(file, cmd.InOrStdin())
 				if err != nil {
 					return err
 				}
-				data.links = append(data.links, links...)
+				links = append(links, fileLinks...)
+				rejected = append(rejected, fileRejected...)
 			}
-			if len(data.links) == 0 {
-				return errors.New("no links specified")
+			if data.clipboard {
+				text, err := readClipboard()
+				if err != nil {
+					return err
+				}
+				clipLinks, clipRejected, err := parseLinks(strings.NewReader(text), "clipboard")
+				if err != nil {
+					return err
+				}
+				links = append(links, clipLinks...)
+				rejected = append(rejected, clipRejected...)
+			}
+			if len(rejected) > 0 {
+				errOut := cmd.ErrOrStderr()
+				fmt.Fprintf(errOut, "%d lines rejected:\n", len(rejected))
+				for _, r := range rejected {
+					fmt.Fprintf(errOut, "  %s\n", r)
+				}
+			}
+			data.links = dedupLinks(links)
+			if len(data.links) == 0 && len(containers) == 0 {
+				return withExitCode(exitCodeUsage, errors.New("no links specified"))
+			}
+			if data.wait.confirmOnline {
+				data.wait.wait = true
+			}
+			if data.wait.wait && data.wait.poll <= 0 {
+				return errors.New("poll interval must be positive")
 			}
-			return doWithDevice(data.debug, data.device, out, func(dev jdownloader.Device) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
 				opts := make([]jdownloader.AddLinksOptions, 0)
 				opts = append(opts, jdownloader.AddLinksOptionAutostart(data.autoStart))
 				if len(data.packageName) > 0 {
@@ -80,98 +184,198 @@ func newAddLinksCommand(out io.Writer) *cobra.Command {
 				if len(data.downloadDir) > 0 {
 					opts = append(opts, jdownloader.AddLinksOptionDestinationDir(data.downloadDir))
 				}
+				for file, content := range containers {
+					if err := dev.LinkGrabber().AddContainer(containerType(file), content); err != nil {
+						return fmt.Errorf("%s: %w", file, err)
+					}
+					fmt.Fprintf(out, "Container %s added\n", file)
+				}
+				if len(data.links) == 0 {
+					return nil
+				}
+				known := make(map[int64]bool)
+				if data.wait.wait {
+					links, err := dev.LinkGrabber().Links()
+					if err != nil {
+						return err
+					}
+					for _, link := range *links {
+						if link.Uuid != nil {
+							known[*link.Uuid] = true
+						}
+					}
+				}
 				resp, err := dev.LinkGrabber().Add(data.links, opts...)
 				if err != nil {
 					return err
 				}
-				fmt.Fprintf(out, "Response: %v\n", resp.Data)
-				return nil
+				jobId, ok := crawlJobId(resp.Data)
+				if !ok {
+					if data.wait.wait {
+						return fmt.Errorf("unable to determine crawler job from response: %v", resp.Data)
+					}
+					fmt.Fprintf(out, "Response: %v\n", resp.Data)
+					return nil
+				}
+				fmt.Fprintf(out, "%d links added, crawler job %d\n", len(data.links), jobId)
+				if !data.wait.wait {
+					return nil
+				}
+				return waitForCrawl(cmd.Context(), out, dev, jobId, known, &data.wait, data.autoStart)
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
 	c.Flags().StringArrayVar(&data.links, "link", data.links, "Link to add. Can be specified multiple times")
-	c.Flags().StringVar(&data.fromFile, "from-file", data.fromFile, "Path to file which contains URL on each line. Lines starting with ';' will be ignored")
+	c.Flags().StringArrayVar(&data.fromFile, "from-file", data.fromFile, "Path to file which contains URLs or to DLC, CCF or RSDF container, '-' reads standard input. Can be specified multiple times")
+	c.Flags().BoolVar(&data.clipboard, "from-clipboard", data.clipboard, "Read URLs from clipboard")
 	c.Flags().StringVar(&data.downloadDir, "download-dir", data.downloadDir, "Directory where to download files")
 	c.Flags().StringVar(&data.packageName, "package-name", data.packageName, "Name of download package")
 	c.Flags().BoolVar(&data.autoStart, "auto-start", data.autoStart, "Flag to determine whether files should start to download immediately or not")
+	c.Flags().BoolVar(&data.wait.wait, "wait", data.wait.wait, "Wait until links are crawled and their availability is checked, then show them")
+	c.Flags().DurationVar(&data.wait.timeout, "wait-timeout", data.wait.timeout, "Maximum time to wait for crawler, 0 means no limit")
+	c.Flags().DurationVar(&data.wait.poll, "poll", data.wait.poll, "Interval between crawler status checks")
+	c.Flags().BoolVar(&data.wait.confirmOnline, "confirm-when-online", data.wait.confirmOnline, "Wait for crawler and move online links to download list (implies --wait)")
 	return c
 }
 
 func newListLinksCommand(out io.Writer) *cobra.Command {
 	type listData struct {
-		commonData
-		json bool
+		list listOptions
 	}
 	var data listData
 	c := &cobra.Command{
 		Use:   "list",
 		Short: "List all links in LinkGrabber",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doWithDevice(data.debug, data.device, out, func(dev jdownloader.Device) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
 				links, err := dev.LinkGrabber().Links()
 				if err != nil {
 					return err
 				}
-
-				if data.json {
-					jsonObj, err := json.MarshalIndent(links, "", "    ")
-					if err != nil {
-						return err
-					}
-					fmt.Printf("%s\n", jsonObj)
-					return nil
-				}
-
-				if len(*links) > 0 {
-					tbl := tablewriter.NewWriter(os.Stdout)
-					tbl.Header(clCols)
-
-					for _, link := range *links {
-						row := make([]string, len(clCols))
-						row[0] = strconv.FormatUint(uint64(*link.Uuid), 10)
-						row[1] = *link.Name
-						row[2] = compressUrl(*link.Url)
-						if link.Status != nil {
-							row[3] = *link.Status
-						}
-						if link.BytesTotal != nil {
-							size := int64(*link.BytesTotal)
-							row[4] = formatSize(&size)
-						}
-						tbl.Append(row)
-					}
-					return tbl.Render()
-				} else {
+				p := cliContextOf(cmd).printer()
+				if len(*links) == 0 && p.isTable() {
 					fmt.Fprintf(out, "No links\n")
+					return nil
 				}
-				return nil
+				return printListing(p, data.list, *links, clCols, clExtraCols)
 			})
 		},
 	}
-	addDebugFlag(c.Flags(), &data.debug)
-	addDeviceFlag(c.Flags(), &data.device)
-	addJsonFlag(c.Flags(), &data.json)
+	addListFlags(c.Flags(), &data.list)
 	return c
 }
 
-func parseLinksFromFile(file string) ([]string, error) {
-	data, err := os.ReadFile(file)
+// rejectedLine is line of input which doesn't contain any valid link.
+type rejectedLine struct {
+	source string
+	line   int
+	text   string
+}
+
+func (r rejectedLine) String() string {
+	if r.line > 0 {
+		return fmt.Sprintf("%s:%d: %s", r.source, r.line, r.text)
+	}
+	return fmt.Sprintf("%s: %s", r.source, r.text)
+}
+
+// parseLinksFromFile reads links from file, "-" stands for standard input.
+func parseLinksFromFile(file string, in io.Reader) ([]string, []rejectedLine, error) {
+	if file == "-" {
+		return parseLinks(in, "stdin")
+	}
+	f, err := os.Open(file)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return parseLinks(f, file)
+}
+
+// parseLinks reads links from text, one or more per line. Lines starting with '#' or ';' are ignored.
+// Links are also extracted from arbitrary text or HTML, lines without any valid link are rejected.
+func parseLinks(r io.Reader, source string) ([]string, []rejectedLine, error) {
 	res := make([]string, 0)
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if len(line) == 0 || line[0] == ';' {
+	rejected := make([]rejectedLine, 0)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for num := 1; sc.Scan(); num++ {
+		line := strings.TrimSpace(sc.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
 			continue
 		}
-		_, err = url.Parse(line)
-		if err == nil {
+		if validLink(line) {
 			res = append(res, line)
+			continue
+		}
+		links := extractLinks(line)
+		if len(links) == 0 {
+			rejected = append(rejected, rejectedLine{source: source, line: num, text: line})
+		}
+		res = append(res, links...)
+	}
+	return res, rejected, sc.Err()
+}
+
+// extractLinks finds links embedded in text, HTML entities are unescaped and trailing punctuation is dropped.
+func extractLinks(text string) []string {
+	res := make([]string, 0)
+	for _, m := range embeddedLinkRe.FindAllString(text, -1) {
+		m = strings.TrimRight(html.UnescapeString(m), ".,;:!?)]}")
+		if validLink(m) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// validLink tells whether s is absolute URL with supported scheme and host.
+func validLink(s string) bool {
+	if strings.ContainsAny(s, " \t") {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(linkSchemes, strings.ToLower(u.Scheme)) && len(u.Host) > 0
+}
+
+// dedupLinks removes repeated links, keeping order of first occurrences.
+func dedupLinks(links []string) []string {
+	seen := make(map[string]bool, len(links))
+	res := make([]string, 0, len(links))
+	for _, link := range links {
+		if !seen[link] {
+			seen[link] = true
+			res = append(res, link)
+		}
+	}
+	return res
+}
+
+// readClipboard returns content of system clipboard using first available clipboard tool.
+func readClipboard() (string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	default:
+		candidates = [][]string{{"wl-paste", "-n"}, {"xclip", "-selection", "clipboard", "-o"}, {"xsel", "--clipboard", "--output"}}
+	}
+	for _, cmd := range candidates {
+		if _, err := exec.LookPath(cmd[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(cmd[0], cmd[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("%s failed: %w", cmd[0], err)
 		}
+		return string(out), nil
 	}
-	return res, nil
+	return "", errors.New("no clipboard tool found")
 }
@@ -17,12 +17,52 @@ limitations under the License.
 package internal
 
 import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestParseLinksFromFile(t *testing.T) {
-	_, err := parseLinksFromFile("../testdata/links-bug-21.txt")
+	_, _, err := parseLinksFromFile("../testdata/links-bug-21.txt", nil)
 	assert.NoError(t, err)
 }
+
+func TestParseLinksMixed(t *testing.T) {
+	links, rejected, err := parseLinksFromFile("../testdata/links-mixed.txt", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://example.com/file1.zip",
+		"https://example.com/file2.zip?a=1&b=2",
+		"https://example.com/file1.zip",
+		"ftp://ftp.example.com/pub/file3.iso",
+	}, links)
+	assert.Len(t, rejected, 2)
+	assert.Equal(t, "../testdata/links-mixed.txt:6: not a link", rejected[0].String())
+	assert.Len(t, dedupLinks(links), 3)
+}
+
+func TestParseLinksStdin(t *testing.T) {
+	links, rejected, err := parseLinksFromFile("-", strings.NewReader("https://a.com/1\nhttp://b.com/2\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, []string{"https://a.com/1", "http://b.com/2"}, links)
+}
+
+func TestValidLink(t *testing.T) {
+	assert.True(t, validLink("https://example.com/a"))
+	assert.False(t, validLink("example.com/a"))
+	assert.False(t, validLink("mailto:me@example.com"))
+	assert.False(t, validLink("https:///path"))
+}
+
+func TestAddLinksReportsRejectedToStderr(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	var out, errOut bytes.Buffer
+	Execute(context.Background(), []string{"links", "add", "not-a-link"}, strings.NewReader(""), &out, &errOut)
+	assert.Empty(t, out.String())
+	assert.Contains(t, errOut.String(), "1 lines rejected:\n  argument: not-a-link\n")
+}
@@ -23,14 +23,12 @@ import (
 	"os"
 	"strings"
 
-	"github.com/rkosegi/jdownloader-go/jdownloader"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
 func newLoginCommand(in io.Reader, out io.Writer) *cobra.Command {
-	debug := false
-	c := &cobra.Command{
+	return &cobra.Command{
 		Use:   "login",
 		Short: "Login into account and safe credentials into config file",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -47,20 +45,37 @@ func newLoginCommand(in io.Reader, out io.Writer) *cobra.Command {
 				return err
 			}
 			password := strings.TrimSpace(string(bytePassword))
-			logger := getLogger(debug)
-			client := jdownloader.NewClient(username, password, logger)
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			cc := cliContextOf(cmd)
+			if err = cc.applyConfig(cfg.Api); err != nil {
+				return err
+			}
+			client := cc.newClient(&contextData{Mail: &username, Password: &password})
 			err = client.Connect()
 			if err != nil {
 				return err
 			}
 			defer clientCloser(client, out)
-			var cfg configData
-			cfg.Mail = &username
-			cfg.Password = &password
-
-			return saveConfig(&cfg)
+			name := cfg.resolveContextName(cc.context)
+			if err = dropSession(cc, cfg, name); err != nil {
+				return err
+			}
+			ctx, ok := cfg.Contexts[name]
+			if !ok {
+				ctx = &contextData{}
+				cfg.Contexts[name] = ctx
+			}
+			ctx.Mail = &username
+			if err = cfg.setPassword(name, &password); err != nil {
+				return err
+			}
+			if len(cfg.CurrentContext) == 0 {
+				cfg.CurrentContext = name
+			}
+			return saveConfig(cfg)
 		},
 	}
-	addDebugFlag(c.Flags(), &debug)
-	return c
 }
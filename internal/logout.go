@@ -17,15 +17,34 @@ limitations under the License.
 package internal
 
 import (
+	"io"
+
 	"github.com/spf13/cobra"
 )
 
-func newLogoutCommand() *cobra.Command {
+func newLogoutCommand(out io.Writer) *cobra.Command {
 	return &cobra.Command{
 		Use:   "logout",
-		Short: "Forget configured authentication credentials",
+		Short: "Forget authentication credentials of current (or --context) context",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return saveConfig(&configData{})
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			cc := cliContextOf(cmd)
+			name := cfg.resolveContextName(cc.context)
+			ctx, err := cfg.context(name)
+			if err != nil {
+				return err
+			}
+			if err = dropSession(cc, cfg, name); err != nil {
+				return err
+			}
+			ctx.Mail = nil
+			if err = cfg.setPassword(name, nil); err != nil {
+				return err
+			}
+			return saveConfig(cfg)
 		},
 	}
 }
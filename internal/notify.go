@@ -0,0 +1,446 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const (
+	notifyStateFileName   = "jdnotify.state"
+	defaultNotifyTemplate = `{{.Kind}} "{{.Name}}" {{.Event}} on {{.Device}}`
+	notifyEventFinished   = linkOutcomeFinished
+	notifyEventFailed     = linkOutcomeFailed
+	notifyEventOffline    = linkOutcomeOffline
+	notifyKindPackage     = "package"
+	notifyKindLink        = "link"
+	notifyTypeWebhook     = "webhook"
+	notifyTypeSlack       = "slack"
+	notifyTypeDiscord     = "discord"
+	notifyTypeMatrix      = "matrix"
+	notifyTypeNtfy        = "ntfy"
+	notifyTypeGotify      = "gotify"
+	notifyTypeEmail       = "email"
+	notifyHttpTimeout     = 30 * time.Second
+)
+
+var notifyTypes = []string{notifyTypeWebhook, notifyTypeSlack, notifyTypeDiscord, notifyTypeMatrix,
+	notifyTypeNtfy, notifyTypeGotify, notifyTypeEmail}
+
+type smtpConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// notificationConfig describes single notification sink in config file.
+type notificationConfig struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`
+	Url      string            `yaml:"url,omitempty"`
+	Token    string            `yaml:"token,omitempty"`
+	Room     string            `yaml:"room,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Events   []string          `yaml:"events,omitempty"`
+	Template string            `yaml:"template,omitempty"`
+	Smtp     *smtpConfig       `yaml:"smtp,omitempty"`
+}
+
+type notifyEvent struct {
+	Kind    string    `json:"kind"`
+	Event   string    `json:"event"`
+	Uuid    int64     `json:"uuid"`
+	Name    string    `json:"name"`
+	Package string    `json:"package,omitempty"`
+	SaveTo  string    `json:"saveTo,omitempty"`
+	Url     string    `json:"url,omitempty"`
+	Device  string    `json:"device"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+func (ev *notifyEvent) key() string {
+	return fmt.Sprintf("%s:%d:%s", ev.Kind, ev.Uuid, ev.Event)
+}
+
+type notifier interface {
+	notify(ev *notifyEvent) error
+}
+
+// notificationSink renders message of event and delivers it using notifier, if event is subscribed.
+type notificationSink struct {
+	name     string
+	events   []string
+	template *template.Template
+	notifier notifier
+}
+
+// send delivers event, false is returned when sink is not subscribed to event.
+func (s *notificationSink) send(ev notifyEvent) (bool, error) {
+	if len(s.events) > 0 && !slices.Contains(s.events, ev.Event) {
+		return false, nil
+	}
+	var sb strings.Builder
+	if err := s.template.Execute(&sb, ev); err != nil {
+		return false, err
+	}
+	ev.Message = sb.String()
+	return true, s.notifier.notify(&ev)
+}
+
+func newNotificationSink(cfg notificationConfig, client *http.Client) (*notificationSink, error) {
+	text := cfg.Template
+	if len(text) == 0 {
+		text = defaultNotifyTemplate
+	}
+	tpl, err := template.New(cfg.Name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var n notifier
+	switch cfg.Type {
+	case notifyTypeWebhook, notifyTypeSlack, notifyTypeDiscord, notifyTypeNtfy, notifyTypeGotify, notifyTypeMatrix:
+		if len(cfg.Url) == 0 {
+			return nil, fmt.Errorf("notification '%s' requires url", cfg.Name)
+		}
+		n = &httpNotifier{cfg: cfg, client: client}
+	case notifyTypeEmail:
+		if cfg.Smtp == nil || len(cfg.Smtp.Host) == 0 || len(cfg.Smtp.To) == 0 {
+			return nil, fmt.Errorf("notification '%s' requires smtp host and recipients", cfg.Name)
+		}
+		n = &emailNotifier{cfg: *cfg.Smtp}
+	default:
+		return nil, fmt.Errorf("notification '%s' has unsupported type '%s', must be one of: %s",
+			cfg.Name, cfg.Type, strings.Join(notifyTypes, "|"))
+	}
+	return &notificationSink{name: cfg.Name, events: cfg.Events, template: tpl, notifier: n}, nil
+}
+
+type httpNotifier struct {
+	cfg    notificationConfig
+	client *http.Client
+}
+
+func (h *httpNotifier) notify(ev *notifyEvent) error {
+	var (
+		body    interface{}
+		method  = http.MethodPost
+		target  = h.cfg.Url
+		headers = map[string]string{"Content-Type": "application/json"}
+	)
+	switch h.cfg.Type {
+	case notifyTypeWebhook:
+		body = ev
+	case notifyTypeSlack:
+		body = map[string]string{"text": ev.Message}
+	case notifyTypeDiscord:
+		body = map[string]string{"content": ev.Message}
+	case notifyTypeGotify:
+		target = strings.TrimSuffix(target, "/") + "/message?token=" + url.QueryEscape(h.cfg.Token)
+		body = map[string]interface{}{"title": "jdcli", "message": ev.Message, "priority": 5}
+	case notifyTypeMatrix:
+		method = http.MethodPut
+		target = fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/jdcli-%s-%d",
+			strings.TrimSuffix(target, "/"), url.PathEscape(h.cfg.Room), strings.ReplaceAll(ev.key(), ":", "-"), time.Now().UnixNano())
+		headers["Authorization"] = "Bearer " + h.cfg.Token
+		body = map[string]string{"msgtype": "m.text", "body": ev.Message}
+	case notifyTypeNtfy:
+		headers = map[string]string{"Title": "jdcli", "Tags": ev.Event}
+		if len(h.cfg.Token) > 0 {
+			headers["Authorization"] = "Bearer " + h.cfg.Token
+		}
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	} else {
+		payload = []byte(ev.Message)
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range h.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification failed with HTTP status %s", resp.Status)
+	}
+	return nil
+}
+
+type emailNotifier struct {
+	cfg smtpConfig
+}
+
+func (e *emailNotifier) notify(ev *notifyEvent) error {
+	port := e.cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if len(e.cfg.Username) > 0 {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(e.cfg.Host, strconv.Itoa(port)), auth, e.cfg.From, e.cfg.To, e.message(ev))
+}
+
+func (e *emailNotifier) message(ev *notifyEvent) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	subject := strings.Join(strings.Fields(fmt.Sprintf("jdcli: %s %s %s", ev.Kind, ev.Name, ev.Event)), " ")
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", ev.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(ev.Message, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// linkEvent classifies settled link, empty string is returned for links which are still in progress.
+func linkEvent(link jdownloader.DownloadLink) string {
+	return linkOutcome(link)
+}
+
+// collectEvents turns current state of packages and links into list of events.
+func collectEvents(device string, pkgs []jdownloader.FilePackage, links []jdownloader.DownloadLink) []notifyEvent {
+	now := time.Now()
+	pkgNames := make(map[int64]string)
+	res := make([]notifyEvent, 0)
+	for _, pkg := range pkgs {
+		if pkg.Uuid == nil {
+			continue
+		}
+		pkgNames[*pkg.Uuid] = strVal(pkg.Name)
+		if event := packageEvent(pkg, links); len(event) > 0 {
+			res = append(res, notifyEvent{Kind: notifyKindPackage, Event: event, Uuid: *pkg.Uuid,
+				Name: strVal(pkg.Name), SaveTo: strVal(pkg.SaveTo), Device: device, Time: now})
+		}
+	}
+	for _, link := range links {
+		if link.Uuid == nil {
+			continue
+		}
+		if event := linkEvent(link); len(event) > 0 {
+			ev := notifyEvent{Kind: notifyKindLink, Event: event, Uuid: *link.Uuid, Name: strVal(link.Name),
+				Url: strVal(link.Url), Device: device, Time: now}
+			if link.PackageUuid != nil {
+				ev.Package = pkgNames[*link.PackageUuid]
+			}
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+func loadNotificationSinks(only string) ([]*notificationSink, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: notifyHttpTimeout}
+	sinks := make([]*notificationSink, 0, len(cfg.Notifications))
+	for _, nc := range cfg.Notifications {
+		if len(only) > 0 && nc.Name != only {
+			continue
+		}
+		sink, err := newNotificationSink(nc, client)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		return nil, errors.New("no notifications configured (see 'notifications' in config file)")
+	}
+	return sinks, nil
+}
+
+func newNotifyCommand(out io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "notify",
+		Short: "Sends notifications about download events",
+	}
+	c.AddCommand(newNotifyWatchCommand(out))
+	c.AddCommand(newNotifyTestCommand(out))
+	return c
+}
+
+func newNotifyWatchCommand(out io.Writer) *cobra.Command {
+	type watchData struct {
+		stateFile string
+		poll      time.Duration
+		once      bool
+	}
+	var data watchData
+	data.poll = 30 * time.Second
+	c := &cobra.Command{
+		Use:   "watch",
+		Short: "Poll downloads and send notification when links or packages finish, fail or go offline",
+		Long: `Poll downloads and send notification when links or packages finish, fail or go offline.
+Every event is sent only once, sent events are recorded in state file. Event which no notification accepted
+is sent again on next check. When state file does not exist yet,
+events of links and packages which already settled are recorded without sending notifications.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinks, err := loadNotificationSinks("")
+			if err != nil {
+				return err
+			}
+			if len(data.stateFile) == 0 {
+				cfgPath, err := getConfigPath()
+				if err != nil {
+					return err
+				}
+				data.stateFile = filepath.Join(filepath.Dir(cfgPath), notifyStateFileName)
+			}
+			_, err = os.Stat(data.stateFile)
+			seed := os.IsNotExist(err)
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				for {
+					state, err := readWatchState(data.stateFile)
+					if err != nil {
+						return err
+					}
+					pkgs, err := dl.Packages()
+					if err != nil {
+						return err
+					}
+					links, err := dl.Links()
+					if err != nil {
+						return err
+					}
+					for _, ev := range collectEvents(dev.Name(), *pkgs, *links) {
+						if _, sent := state[ev.key()]; sent {
+							continue
+						}
+						if !seed {
+							if err = dispatchEvent(out, sinks, ev); err != nil {
+								fmt.Fprintf(out, "Event %s not sent, will retry on next check: %v\n", ev.key(), err)
+								continue
+							}
+						}
+						state[ev.key()] = watchStateEntry{Event: ev.Event, Time: ev.Time}
+					}
+					seed = false
+					if err = writeWatchState(data.stateFile, state); err != nil {
+						return err
+					}
+					if data.once {
+						return nil
+					}
+					if err = sleepCtx(cmd.Context(), data.poll); err != nil {
+						return err
+					}
+				}
+			})
+		},
+	}
+	c.Flags().StringVar(&data.stateFile, "state-file", data.stateFile, "Path to file which records already sent events (defaults to file next to config)")
+	c.Flags().DurationVar(&data.poll, "poll", data.poll, "Interval between status checks")
+	c.Flags().BoolVar(&data.once, "once", data.once, "Check downloads only once and exit, useful when run from cron")
+	return c
+}
+
+// dispatchEvent sends event to all sinks subscribed to it. Error is returned only when none of them delivered
+// the event, failures of individual sinks are reported to out otherwise.
+func dispatchEvent(out io.Writer, sinks []*notificationSink, ev notifyEvent) error {
+	errs := make([]error, 0)
+	delivered := 0
+	for _, sink := range sinks {
+		sent, err := sink.send(ev)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("notification '%s' failed: %w", sink.name, err))
+		case sent:
+			delivered++
+		}
+	}
+	if delivered > 0 || len(errs) == 0 {
+		for _, err := range errs {
+			fmt.Fprintln(out, err)
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func newNotifyTestCommand(out io.Writer) *cobra.Command {
+	var name string
+	c := &cobra.Command{
+		Use:   "test",
+		Short: "Send test event to all (or --name) configured notifications",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sinks, err := loadNotificationSinks(name)
+			if err != nil {
+				return err
+			}
+			ev := notifyEvent{Kind: notifyKindPackage, Event: notifyEventFinished, Name: "jdcli test",
+				Device: "test", Time: time.Now()}
+			for _, sink := range sinks {
+				sent, err := sink.send(ev)
+				if err != nil {
+					return fmt.Errorf("notification '%s' failed: %w", sink.name, err)
+				}
+				if sent {
+					fmt.Fprintf(out, "Notification '%s' sent\n", sink.name)
+				} else {
+					fmt.Fprintf(out, "Notification '%s' skipped, it is not subscribed to %s events\n", sink.name, ev.Event)
+				}
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVar(&name, "name", name, "Name of notification to test")
+	return c
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+type capturedRequest struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	body   string
+}
+
+func newNotifyServer(t *testing.T) (*httptest.Server, *[]capturedRequest) {
+	reqs := make([]capturedRequest, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, capturedRequest{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery,
+			header: r.Header, body: string(body)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func TestCollectEvents(t *testing.T) {
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("pkg1")}}
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Name: pstr("a"), Finished: pbool(true)},
+		{Uuid: pint64(2), PackageUuid: pint64(10), Name: pstr("b"), StatusIconKey: pstr("false")},
+		{Uuid: pint64(3), PackageUuid: pint64(10), Name: pstr("c"), Running: pbool(true)},
+	}
+	events := collectEvents("dev", pkgs, links)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "link:1:finished", events[0].key())
+	assert.Equal(t, "pkg1", events[0].Package)
+	assert.Equal(t, "link:2:offline", events[1].key())
+
+	links[2].Running, links[2].Finished = nil, pbool(true)
+	events = collectEvents("dev", pkgs, links)
+	assert.Len(t, events, 4)
+	assert.Equal(t, "package:10:failed", events[0].key())
+}
+
+func TestNotificationSinks(t *testing.T) {
+	srv, reqs := newNotifyServer(t)
+	ev := notifyEvent{Kind: notifyKindPackage, Event: notifyEventFinished, Uuid: 10, Name: "pkg1",
+		Device: "dev", Time: time.Now()}
+	for _, nc := range []notificationConfig{
+		{Name: "hook", Type: notifyTypeWebhook, Url: srv.URL + "/hook", Headers: map[string]string{"X-Token": "abc"}},
+		{Name: "slack", Type: notifyTypeSlack, Url: srv.URL + "/slack", Template: "{{.Name}} is {{.Event}}"},
+		{Name: "discord", Type: notifyTypeDiscord, Url: srv.URL + "/discord"},
+		{Name: "matrix", Type: notifyTypeMatrix, Url: srv.URL, Room: "!room:example.org", Token: "mtx"},
+		{Name: "ntfy", Type: notifyTypeNtfy, Url: srv.URL + "/jd"},
+		{Name: "gotify", Type: notifyTypeGotify, Url: srv.URL, Token: "gtf"},
+		{Name: "skipped", Type: notifyTypeSlack, Url: srv.URL + "/skipped", Events: []string{notifyEventOffline}},
+	} {
+		sink, err := newNotificationSink(nc, srv.Client())
+		assert.NoError(t, err)
+		sent, err := sink.send(ev)
+		assert.NoError(t, err)
+		assert.Equal(t, nc.Name != "skipped", sent)
+	}
+	assert.Len(t, *reqs, 6)
+
+	var hook notifyEvent
+	assert.NoError(t, json.Unmarshal([]byte((*reqs)[0].body), &hook))
+	assert.Equal(t, "package \"pkg1\" finished on dev", hook.Message)
+	assert.Equal(t, "abc", (*reqs)[0].header.Get("X-Token"))
+	assert.JSONEq(t, `{"text":"pkg1 is finished"}`, (*reqs)[1].body)
+	assert.Contains(t, (*reqs)[2].body, `"content"`)
+	assert.Equal(t, http.MethodPut, (*reqs)[3].method)
+	assert.True(t, strings.HasPrefix((*reqs)[3].path, "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/"))
+	assert.Equal(t, "Bearer mtx", (*reqs)[3].header.Get("Authorization"))
+	assert.Equal(t, "package \"pkg1\" finished on dev", (*reqs)[4].body)
+	assert.Equal(t, "token=gtf", (*reqs)[5].query)
+}
+
+func TestNotificationSinkErrors(t *testing.T) {
+	_, err := newNotificationSink(notificationConfig{Name: "x", Type: "pager"}, http.DefaultClient)
+	assert.Error(t, err)
+	_, err = newNotificationSink(notificationConfig{Name: "x", Type: notifyTypeSlack}, http.DefaultClient)
+	assert.Error(t, err)
+	_, err = newNotificationSink(notificationConfig{Name: "x", Type: notifyTypeEmail}, http.DefaultClient)
+	assert.Error(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	sink, err := newNotificationSink(notificationConfig{Name: "x", Type: notifyTypeDiscord, Url: srv.URL}, srv.Client())
+	assert.NoError(t, err)
+	_, err = sink.send(notifyEvent{Kind: notifyKindLink, Event: notifyEventFailed})
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	ev := notifyEvent{Kind: notifyKindLink, Event: notifyEventFailed}
+	assert.Error(t, dispatchEvent(&buf, []*notificationSink{sink}, ev))
+	assert.Empty(t, buf.String())
+	unsubscribed, err := newNotificationSink(notificationConfig{Name: "unsubscribed", Type: notifyTypeWebhook, Url: srv.URL,
+		Events: []string{notifyEventFinished}}, srv.Client())
+	assert.NoError(t, err)
+	assert.Error(t, dispatchEvent(&buf, []*notificationSink{sink, unsubscribed}, ev))
+	assert.NoError(t, dispatchEvent(&buf, []*notificationSink{unsubscribed}, ev))
+	assert.Empty(t, buf.String())
+
+	okSrv, _ := newNotifyServer(t)
+	ok, err := newNotificationSink(notificationConfig{Name: "ok", Type: notifyTypeWebhook, Url: okSrv.URL}, okSrv.Client())
+	assert.NoError(t, err)
+	assert.NoError(t, dispatchEvent(&buf, []*notificationSink{sink, unsubscribed, ok}, ev))
+	assert.Equal(t, "notification 'x' failed: notification failed with HTTP status 403 Forbidden\n", buf.String())
+}
+
+func TestEmailMessage(t *testing.T) {
+	e := &emailNotifier{cfg: smtpConfig{From: "jd@example.com", To: []string{"a@example.com", "b@example.com"}}}
+	msg := string(e.message(&notifyEvent{Kind: notifyKindLink, Name: "file.zip", Event: notifyEventFailed,
+		Time: time.Now(), Message: "line1\nline2"}))
+	assert.Contains(t, msg, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, msg, "Subject: jdcli: link file.zip failed\r\n")
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\nline1\r\nline2\r\n"))
+
+	msg = string(e.message(&notifyEvent{Kind: notifyKindLink, Name: "evil\r\nBcc: x@example.com\nfilé.zip",
+		Event: notifyEventFailed, Time: time.Now()}))
+	assert.NotContains(t, msg, "\r\nBcc:")
+	assert.Contains(t, msg, "Subject: =?utf-8?q?jdcli:_link_evil_Bcc:_x@example.com_fil=C3=A9.zip_failed?=\r\n")
+}
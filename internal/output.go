@@ -0,0 +1,473 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputWide  = "wide"
+	outputJson  = "json"
+	outputYaml  = "yaml"
+	outputCsv   = "csv"
+	outputTsv   = "tsv"
+
+	outputGoTemplate = "go-template"
+	outputJsonPath   = "jsonpath"
+)
+
+var (
+	outputFormats     = []string{outputTable, outputWide, outputJson, outputYaml, outputCsv, outputTsv}
+	outputFormatsHelp = strings.Join(append(outputFormats, outputGoTemplate+"=...", outputJsonPath+"=..."), "|")
+)
+
+// column describes single field of listed item, shared by all output formats.
+// Wide columns are only rendered by "wide", "csv" and "tsv" formats.
+// Optional key is used for sorting instead of rendered value, so that sizes and durations sort numerically.
+type column[T any] struct {
+	name  string
+	wide  bool
+	value func(T) string
+	key   func(T) any
+}
+
+// printer writes command output in format chosen by global --output flag.
+type printer struct {
+	out    io.Writer
+	format outputFormat
+}
+
+// listOptions controls which items of list are rendered and how.
+type listOptions struct {
+	filter    string
+	sortBy    []string
+	columns   []string
+	noHeaders bool
+	limit     int
+	offset    int
+	maxWidth  int
+}
+
+type outputFormat string
+
+func (o *outputFormat) String() string {
+	return string(*o)
+}
+
+func (o *outputFormat) Set(v string) error {
+	if tpl, ok := strings.CutPrefix(v, outputGoTemplate+"="); ok {
+		if _, err := parseGoTemplate(tpl); err != nil {
+			return err
+		}
+		*o = outputFormat(v)
+		return nil
+	}
+	if tpl, ok := strings.CutPrefix(v, outputJsonPath+"="); ok {
+		if _, err := parseJsonPath(tpl); err != nil {
+			return err
+		}
+		*o = outputFormat(v)
+		return nil
+	}
+	for _, f := range outputFormats {
+		if v == f {
+			*o = outputFormat(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format '%s', must be one of: %s", v, outputFormatsHelp)
+}
+
+func (o *outputFormat) Type() string {
+	return "format"
+}
+
+func (o *outputFormat) isTable() bool {
+	return *o == "" || *o == outputTable || *o == outputWide
+}
+
+func (p printer) isTable() bool {
+	return p.format.isTable()
+}
+
+// jsonOutputFlag keeps legacy --json flag working as an alias of "--output json".
+type jsonOutputFlag struct {
+	target *outputFormat
+}
+
+func (j jsonOutputFlag) String() string {
+	return "false"
+}
+
+func (j jsonOutputFlag) Set(v string) error {
+	if v == "true" {
+		*j.target = outputJson
+	}
+	return nil
+}
+
+func (j jsonOutputFlag) Type() string {
+	return "bool"
+}
+
+func addOutputFlag(fs *pflag.FlagSet, target *outputFormat) {
+	fs.VarP(target, "output", "o", fmt.Sprintf("Output format. One of: %s", outputFormatsHelp))
+	f := fs.VarPF(jsonOutputFlag{target: target}, "json", "", "JSON output flag")
+	f.NoOptDefVal = "true"
+	_ = fs.MarkDeprecated("json", "use --output json instead")
+}
+
+// addListFlags registers flags of listOptions, including filter.
+func addListFlags(fs *pflag.FlagSet, target *listOptions) {
+	addFilterFlag(fs, &target.filter)
+	fs.StringSliceVar(&target.sortBy, "sort-by", target.sortBy, "Comma-separated list of columns to sort by, prefix column with '-' for descending order")
+	fs.StringSliceVar(&target.columns, "columns", target.columns, "Comma-separated list of columns to display in table, csv and tsv output")
+	fs.BoolVar(&target.noHeaders, "no-headers", target.noHeaders, "Don't print headers in table, csv and tsv output")
+	fs.IntVar(&target.limit, "limit", target.limit, "Maximum number of items to display, 0 means no limit")
+	fs.IntVar(&target.offset, "offset", target.offset, "Number of items to skip")
+	fs.IntVar(&target.maxWidth, "max-width", target.maxWidth, "Maximum width of table, 0 means width of terminal, -1 means no limit")
+}
+
+func printList[T any](p printer, items []T, cols []column[T]) error {
+	return printListing(p, listOptions{}, items, cols, nil)
+}
+
+// printListing filters, sorts and paginates items and prints them in requested format.
+// Extra columns are never displayed by default, but they can be chosen by --columns and used by --sort-by.
+func printListing[T any](p printer, opts listOptions, items []T, cols []column[T], extra []column[T]) error {
+	items, err := filterList(items, opts.filter)
+	if err != nil {
+		return err
+	}
+	all := append(slices.Clone(cols), extra...)
+	if err = sortList(items, opts.sortBy, all); err != nil {
+		return err
+	}
+	items = paginate(items, opts.offset, opts.limit)
+	if len(opts.columns) > 0 {
+		if cols, err = pickColumns(all, opts.columns); err != nil {
+			return err
+		}
+	}
+	if tpl, ok := strings.CutPrefix(string(p.format), outputGoTemplate+"="); ok {
+		return printGoTemplate(p.out, tpl, items)
+	}
+	if tpl, ok := strings.CutPrefix(string(p.format), outputJsonPath+"="); ok {
+		return printJsonPath(p.out, tpl, items)
+	}
+	switch p.format {
+	case "", outputTable:
+		return renderTable(p.out, items, selectColumns(cols, false), opts.noHeaders, opts.maxWidth)
+	case outputWide:
+		return renderTable(p.out, items, selectColumns(cols, true), opts.noHeaders, opts.maxWidth)
+	case outputJson:
+		return printJson(p.out, items)
+	case outputYaml:
+		return printYaml(p.out, items)
+	case outputCsv:
+		return printDelimited(p.out, items, cols, ',', opts.noHeaders)
+	case outputTsv:
+		return printDelimited(p.out, items, cols, '\t', opts.noHeaders)
+	default:
+		return fmt.Errorf("unsupported output format: %s", p.format)
+	}
+}
+
+func normalizeColumnName(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+func findColumn[T any](cols []column[T], name string) (column[T], error) {
+	for _, col := range cols {
+		if normalizeColumnName(col.name) == normalizeColumnName(name) {
+			return col, nil
+		}
+	}
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = normalizeColumnName(col.name)
+	}
+	return column[T]{}, fmt.Errorf("unknown column '%s', must be one of: %s", name, strings.Join(names, ","))
+}
+
+// pickColumns returns named columns in given order, picked columns are displayed by all table formats.
+func pickColumns[T any](cols []column[T], names []string) ([]column[T], error) {
+	res := make([]column[T], 0, len(names))
+	for _, name := range names {
+		col, err := findColumn(cols, name)
+		if err != nil {
+			return nil, err
+		}
+		col.wide = false
+		res = append(res, col)
+	}
+	return res, nil
+}
+
+func sortList[T any](items []T, sortBy []string, cols []column[T]) error {
+	type sortKey struct {
+		col  column[T]
+		desc bool
+	}
+	keys := make([]sortKey, 0, len(sortBy))
+	for _, name := range sortBy {
+		desc := strings.HasPrefix(name, "-")
+		col, err := findColumn(cols, strings.TrimPrefix(name, "-"))
+		if err != nil {
+			return err
+		}
+		keys = append(keys, sortKey{col: col, desc: desc})
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		for _, k := range keys {
+			var res int
+			if k.col.key != nil {
+				res = compareKeys(k.col.key(a), k.col.key(b))
+			} else {
+				res = cmp.Compare(k.col.value(a), k.col.value(b))
+			}
+			if k.desc {
+				res = -res
+			}
+			if res != 0 {
+				return res
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// compareKeys compares sort keys, keys of different types are compared as strings.
+func compareKeys(a, b any) int {
+	switch av := a.(type) {
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return cmp.Compare(av, bv)
+		}
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return cmp.Compare(av, bv)
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func paginate[T any](items []T, offset, limit int) []T {
+	offset = min(max(offset, 0), len(items))
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func selectColumns[T any](cols []column[T], wide bool) []column[T] {
+	res := make([]column[T], 0, len(cols))
+	for _, col := range cols {
+		if wide || !col.wide {
+			res = append(res, col)
+		}
+	}
+	return res
+}
+
+func headerRow[T any](cols []column[T]) []string {
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = col.name
+	}
+	return row
+}
+
+func valueRow[T any](item T, cols []column[T]) []string {
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = col.value(item)
+	}
+	return row
+}
+
+func printTable[T any](out io.Writer, items []T, cols []column[T], wide bool) error {
+	return renderTable(out, items, selectColumns(cols, wide), false, 0)
+}
+
+// renderTable truncates cells so that table fits into maxWidth, or into terminal when maxWidth is 0.
+func renderTable[T any](out io.Writer, items []T, cols []column[T], noHeaders bool, maxWidth int) error {
+	if maxWidth == 0 {
+		maxWidth = -1
+		if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			if w, _, err := term.GetSize(int(f.Fd())); err == nil {
+				maxWidth = w
+			}
+		}
+	}
+	rows := make([][]string, 0, len(items)+1)
+	if !noHeaders {
+		rows = append(rows, headerRow(cols))
+	}
+	for _, item := range items {
+		rows = append(rows, valueRow(item, cols))
+	}
+	if maxWidth > 0 {
+		fitColumns(rows, maxWidth)
+	}
+	tbl := tablewriter.NewWriter(out)
+	if !noHeaders && len(rows) > 0 {
+		tbl.Header(rows[0])
+		rows = rows[1:]
+	}
+	for _, row := range rows {
+		if err := tbl.Append(row); err != nil {
+			return err
+		}
+	}
+	return tbl.Render()
+}
+
+// fitColumns shrinks widest columns until rows, including table borders, fit into width.
+func fitColumns(rows [][]string, width int) {
+	if len(rows) == 0 {
+		return
+	}
+	const minWidth = 4
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	available := width - 3*len(widths) - 1
+	for total := sumInts(widths); total > available; total-- {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minWidth {
+			break
+		}
+		widths[widest]--
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if r := []rune(cell); len(r) > widths[i] {
+				row[i] = string(r[:widths[i]-1]) + "…"
+			}
+		}
+	}
+}
+
+func sumInts(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func printDelimited[T any](out io.Writer, items []T, cols []column[T], sep rune, noHeaders bool) error {
+	w := csv.NewWriter(out)
+	w.Comma = sep
+	if !noHeaders {
+		if err := w.Write(headerRow(cols)); err != nil {
+			return err
+		}
+	}
+	for _, item := range items {
+		if err := w.Write(valueRow(item, cols)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printJson(out io.Writer, v interface{}) error {
+	jsonObj, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", jsonObj)
+	return err
+}
+
+// printGoTemplate renders items using their Go field names, e.g. '{{range .}}{{.Uuid}}{{"\n"}}{{end}}'.
+func printGoTemplate(out io.Writer, tpl string, v interface{}) error {
+	t, err := parseGoTemplate(tpl)
+	if err != nil {
+		return err
+	}
+	return t.Execute(out, v)
+}
+
+func parseGoTemplate(tpl string) (*template.Template, error) {
+	return template.New("output").Option("missingkey=error").Parse(tpl)
+}
+
+// printJsonPath renders items using their JSON field names, e.g. '{[*].name}'.
+func printJsonPath(out io.Writer, tpl string, v interface{}) error {
+	jp, err := parseJsonPath(tpl)
+	if err != nil {
+		return err
+	}
+	return jp.execute(out, v)
+}
+
+// printYaml goes through JSON first, so that YAML output uses same field names as JSON output.
+func printYaml(out io.Writer, v interface{}) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDevs = []jdownloader.DeviceInfo{
+	{Id: "1", Type: "jd", Name: "nas", Status: "ONLINE"},
+	{Id: "2", Type: "jd", Name: "desktop", Status: "OFFLINE"},
+}
+
+func TestOutputFormatSet(t *testing.T) {
+	var f outputFormat
+	assert.NoError(t, f.Set("yaml"))
+	assert.Equal(t, outputFormat(outputYaml), f)
+	assert.Error(t, f.Set("xml"))
+}
+
+func TestPrintListCsv(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, printList(printer{out: &buf, format: outputCsv}, testDevs, devCols))
+	assert.Equal(t, "ID,Type,Name,Status\n1,jd,nas,ONLINE\n2,jd,desktop,OFFLINE\n", buf.String())
+	buf.Reset()
+	assert.NoError(t, printList(printer{out: &buf, format: outputTsv}, testDevs[:1], devCols))
+	assert.Equal(t, "ID\tType\tName\tStatus\n1\tjd\tnas\tONLINE\n", buf.String())
+}
+
+func TestPrintListYaml(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, printList(printer{out: &buf, format: outputYaml}, testDevs[:1], devCols))
+	assert.Equal(t, "- id: \"1\"\n  name: nas\n  status: ONLINE\n  type: jd\n", buf.String())
+}
+
+func TestSelectColumns(t *testing.T) {
+	assert.Equal(t, 6, len(selectColumns(dlCols, false)))
+	assert.Equal(t, len(dlCols), len(selectColumns(dlCols, true)))
+}
+
+func TestPrintListingOptions(t *testing.T) {
+	var buf bytes.Buffer
+	opts := listOptions{sortBy: []string{"-size", "id"}, columns: []string{"id", "size", "priority"},
+		offset: 1, limit: 2}
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), BytesTotal: pint64(2048)},
+		{Uuid: pint64(2), BytesTotal: pint64(512), Priority: pstr("HIGH")},
+		{Uuid: pint64(3), BytesTotal: pint64(1 << 20)},
+		{Uuid: pint64(4), BytesTotal: pint64(512)},
+	}
+	assert.NoError(t, printListing(printer{out: &buf, format: outputCsv}, opts, links, dlCols, dlExtraCols))
+	assert.Equal(t, "ID,Size,Priority\n1,2.0 KiB,\n2,512 B,HIGH\n", buf.String())
+
+	buf.Reset()
+	opts = listOptions{noHeaders: true, filter: "size<1KiB", sortBy: []string{"-id"}, columns: []string{"ID"}}
+	assert.NoError(t, printListing(printer{out: &buf, format: outputTsv}, opts, links, dlCols, dlExtraCols))
+	assert.Equal(t, "4\n2\n", buf.String())
+
+	assert.Error(t, printListing(printer{out: &buf}, listOptions{sortBy: []string{"unknown"}}, links, dlCols, dlExtraCols))
+	assert.Error(t, printListing(printer{out: &buf}, listOptions{columns: []string{"unknown"}}, links, dlCols, dlExtraCols))
+}
+
+func TestRenderTableFit(t *testing.T) {
+	var buf bytes.Buffer
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), Url: pstr("https://example.com/" + strings.Repeat("a", 200)), Status: pstr("Finished")},
+	}
+	assert.NoError(t, renderTable(&buf, links, selectColumns(dlCols, false), false, 60))
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		assert.LessOrEqual(t, utf8.RuneCountInString(line), 60, line)
+	}
+	assert.Contains(t, buf.String(), "…")
+
+	buf.Reset()
+	assert.NoError(t, renderTable(&buf, links, selectColumns(dlCols, false), true, -1))
+	assert.Contains(t, buf.String(), strings.Repeat("a", 200))
+	assert.NotContains(t, buf.String(), "URL")
+}
+
+func TestCompareKeys(t *testing.T) {
+	assert.Equal(t, -1, compareKeys(int64(2), int64(10)))
+	assert.Equal(t, 1, compareKeys(2.5, 1.5))
+	assert.Equal(t, -1, compareKeys("a", "b"))
+	assert.NotPanics(t, func() {
+		assert.Equal(t, -compareKeys(nil, int64(10)), compareKeys(int64(10), nil))
+		assert.Equal(t, -1, compareKeys(int64(1), 2.5))
+	})
+}
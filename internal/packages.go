@@ -0,0 +1,348 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+var priorities = []string{"HIGHEST", "HIGHER", "HIGH", "DEFAULT", "LOW", "LOWER", "LOWEST"}
+
+// packageOps are package operations shared by download list and link collector.
+type packageOps interface {
+	RenamePackage(packageId int64, name string) error
+	SetDownloadDirectory(dir string, packageIds []int64) error
+	SetPriority(priority string, linkIds []int64, packageIds []int64) error
+	SetEnabled(enabled bool, linkIds []int64, packageIds []int64) error
+	MovePackages(packageIds []int64, afterDestPackageId *int64) error
+	MoveToNewPackage(linkIds []int64, packageIds []int64, name string, dir string) error
+	SplitPackageByHoster(linkIds []int64, packageIds []int64) error
+}
+
+// packageRef identifies package of either list, refs are kept in order of list.
+type packageRef struct {
+	uuid   int64
+	name   string
+	saveTo string
+}
+
+// packageTarget binds package commands to download list or to link collector.
+type packageTarget struct {
+	ops  func(dev jdownloader.Device) packageOps
+	refs func(dev jdownloader.Device) ([]packageRef, error)
+}
+
+var (
+	downloadPackages = packageTarget{
+		ops: func(dev jdownloader.Device) packageOps { return dev.Downloader() },
+		refs: func(dev jdownloader.Device) ([]packageRef, error) {
+			pkgs, err := dev.Downloader().Packages()
+			if err != nil {
+				return nil, err
+			}
+			return filePackageRefs(*pkgs), nil
+		},
+	}
+	collectorPackages = packageTarget{
+		ops: func(dev jdownloader.Device) packageOps { return dev.LinkGrabber() },
+		refs: func(dev jdownloader.Device) ([]packageRef, error) {
+			pkgs, err := dev.LinkGrabber().Packages()
+			if err != nil {
+				return nil, err
+			}
+			refs := make([]packageRef, 0, len(*pkgs))
+			for _, pkg := range *pkgs {
+				if pkg.Uuid != nil {
+					refs = append(refs, packageRef{uuid: *pkg.Uuid, name: strVal(pkg.Name), saveTo: strVal(pkg.SaveTo)})
+				}
+			}
+			return refs, nil
+		},
+	}
+)
+
+func filePackageRefs(pkgs []jdownloader.FilePackage) []packageRef {
+	refs := make([]packageRef, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Uuid != nil {
+			refs = append(refs, packageRef{uuid: *pkg.Uuid, name: strVal(pkg.Name), saveTo: strVal(pkg.SaveTo)})
+		}
+	}
+	return refs
+}
+
+// matchPackageRefs resolves package selectors (UUIDs or names) and name expression into UUIDs, in order of list.
+func matchPackageRefs(refs []packageRef, selectors []string, name string) ([]int64, error) {
+	nameRe, err := compileNameRe(name)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[int64]bool)
+	for _, sel := range selectors {
+		found := false
+		for _, ref := range refs {
+			if fmt.Sprint(ref.uuid) == sel || ref.name == sel {
+				matched[ref.uuid] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, withExitCode(exitCodeNotFound, fmt.Errorf("package '%s' not found", sel))
+		}
+	}
+	if nameRe != nil {
+		for _, ref := range refs {
+			if nameRe.MatchString(ref.name) {
+				matched[ref.uuid] = true
+			}
+		}
+	}
+	res := make([]int64, 0, len(matched))
+	for _, ref := range refs {
+		if matched[ref.uuid] {
+			res = append(res, ref.uuid)
+		}
+	}
+	return res, nil
+}
+
+// selectExportPackages returns packages selected by UUIDs or names and by name expression, all packages when none is given.
+func selectExportPackages(pkgs []jdownloader.FilePackage, selectors []string, name string) ([]jdownloader.FilePackage, error) {
+	if len(selectors) == 0 && len(name) == 0 {
+		return pkgs, nil
+	}
+	ids, err := matchPackageRefs(filePackageRefs(pkgs), selectors, name)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]jdownloader.FilePackage, 0, len(ids))
+	for _, pkg := range pkgs {
+		if pkg.Uuid != nil && slices.Contains(ids, *pkg.Uuid) {
+			res = append(res, pkg)
+		}
+	}
+	return res, nil
+}
+
+// addPackageCommands adds package management commands of given list to parent command.
+func addPackageCommands(parent *cobra.Command, out io.Writer, target packageTarget) {
+	parent.AddCommand(newPackageRenameCommand(out, target))
+	parent.AddCommand(newPackageSetDirCommand(out, target))
+	parent.AddCommand(newPackagePriorityCommand(out, target))
+	parent.AddCommand(newPackageEnableCommand(out, target, true))
+	parent.AddCommand(newPackageEnableCommand(out, target, false))
+	parent.AddCommand(newPackageMoveCommand(out, target, true))
+	parent.AddCommand(newPackageMoveCommand(out, target, false))
+	parent.AddCommand(newPackageMergeCommand(out, target))
+	parent.AddCommand(newPackageSplitCommand(out, target))
+}
+
+type packageCmdData struct {
+	name string
+}
+
+// argSplitter separates command parameters from package selectors given as arguments.
+type argSplitter func(args []string) (params []string, selectors []string)
+
+func leadingParams(n int) argSplitter {
+	return func(args []string) ([]string, []string) {
+		return args[:n], args[n:]
+	}
+}
+
+func trailingParams(n int) argSplitter {
+	return func(args []string) ([]string, []string) {
+		return args[len(args)-n:], args[:len(args)-n]
+	}
+}
+
+// newPackageCommand creates command operating on packages selected by arguments and by --name expression.
+func newPackageCommand(out io.Writer, target packageTarget, use, short string, args cobra.PositionalArgs, split argSplitter,
+	fn func(ops packageOps, refs []packageRef, ids []int64, args []string) error,
+) *cobra.Command {
+	var data packageCmdData
+	c := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  args,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, selectors := split(args)
+			if len(selectors) == 0 && len(data.name) == 0 {
+				return withExitCode(exitCodeUsage, errors.New("no package specified (use UUID or name arguments, or --name)"))
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				refs, err := target.refs(dev)
+				if err != nil {
+					return err
+				}
+				ids, err := matchPackageRefs(refs, selectors, data.name)
+				if err != nil {
+					return err
+				}
+				if len(ids) == 0 {
+					return withExitCode(exitCodeNotFound, errors.New("no matching packages"))
+				}
+				return fn(target.ops(dev), refs, ids, params)
+			})
+		},
+	}
+	c.Flags().StringVar(&data.name, "name", data.name, "Select packages with name matching regular expression")
+	return c
+}
+
+func newPackageRenameCommand(out io.Writer, target packageTarget) *cobra.Command {
+	return newPackageCommand(out, target, "rename PACKAGE NEW_NAME", "Rename package",
+		cobra.ExactArgs(2), trailingParams(1), func(ops packageOps, _ []packageRef, ids []int64, args []string) error {
+			if len(ids) > 1 {
+				return fmt.Errorf("package selector matched %d packages, rename needs exactly one", len(ids))
+			}
+			if err := ops.RenamePackage(ids[0], args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Package renamed to %s\n", args[0])
+			return nil
+		})
+}
+
+func newPackageSetDirCommand(out io.Writer, target packageTarget) *cobra.Command {
+	return newPackageCommand(out, target, "set-dir DIR [PACKAGE...]", "Change directory where packages are saved",
+		cobra.MinimumNArgs(1), leadingParams(1), func(ops packageOps, _ []packageRef, ids []int64, args []string) error {
+			if err := ops.SetDownloadDirectory(args[0], ids); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Directory of %d packages set to %s\n", len(ids), args[0])
+			return nil
+		})
+}
+
+func newPackagePriorityCommand(out io.Writer, target packageTarget) *cobra.Command {
+	return newPackageCommand(out, target, fmt.Sprintf("priority %s [PACKAGE...]", strings.ToLower(strings.Join(priorities, "|"))),
+		"Set priority of packages", cobra.MinimumNArgs(1), leadingParams(1), func(ops packageOps, _ []packageRef, ids []int64, args []string) error {
+			priority := strings.ToUpper(args[0])
+			if !slices.Contains(priorities, priority) {
+				return fmt.Errorf("invalid priority '%s', must be one of: %s", args[0], strings.ToLower(strings.Join(priorities, ", ")))
+			}
+			if err := ops.SetPriority(priority, []int64{}, ids); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Priority of %d packages set to %s\n", len(ids), strings.ToLower(priority))
+			return nil
+		})
+}
+
+func newPackageEnableCommand(out io.Writer, target packageTarget, enable bool) *cobra.Command {
+	use, short, verb := "enable [PACKAGE...]", "Enable packages", "enabled"
+	if !enable {
+		use, short, verb = "disable [PACKAGE...]", "Disable packages", "disabled"
+	}
+	return newPackageCommand(out, target, use, short, cobra.ArbitraryArgs, leadingParams(0),
+		func(ops packageOps, _ []packageRef, ids []int64, _ []string) error {
+			if err := ops.SetEnabled(enable, []int64{}, ids); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%d packages %s\n", len(ids), verb)
+			return nil
+		})
+}
+
+func newPackageMoveCommand(out io.Writer, target packageTarget, top bool) *cobra.Command {
+	use, short := "move-to-top [PACKAGE...]", "Move packages to top of list"
+	if !top {
+		use, short = "move-to-bottom [PACKAGE...]", "Move packages to bottom of list"
+	}
+	return newPackageCommand(out, target, use, short, cobra.ArbitraryArgs, leadingParams(0),
+		func(ops packageOps, refs []packageRef, ids []int64, _ []string) error {
+			if err := ops.MovePackages(ids, moveDestination(refs, ids, top)); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%d packages moved\n", len(ids))
+			return nil
+		})
+}
+
+// moveDestination returns package after which moved packages are placed, nil means top of list.
+func moveDestination(refs []packageRef, ids []int64, top bool) *int64 {
+	if top {
+		return nil
+	}
+	for i := len(refs) - 1; i >= 0; i-- {
+		if !slices.Contains(ids, refs[i].uuid) {
+			return &refs[i].uuid
+		}
+	}
+	return nil
+}
+
+func newPackageMergeCommand(out io.Writer, target packageTarget) *cobra.Command {
+	var into, dir string
+	c := newPackageCommand(out, target, "merge [PACKAGE...] --into NAME", "Merge packages into single package",
+		cobra.ArbitraryArgs, leadingParams(0), func(ops packageOps, refs []packageRef, ids []int64, _ []string) error {
+			saveTo := dir
+			if len(saveTo) == 0 {
+				saveTo = mergeDirectory(refs, ids, into)
+			}
+			if err := ops.MoveToNewPackage([]int64{}, ids, into, saveTo); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%d packages merged into %s\n", len(ids), into)
+			return nil
+		})
+	c.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if len(into) == 0 {
+			return withExitCode(exitCodeUsage, errors.New("name of merged package is required (use --into)"))
+		}
+		return nil
+	}
+	c.Flags().StringVar(&into, "into", into, "Name of merged package")
+	c.Flags().StringVar(&dir, "dir", dir, "Directory where merged package is saved (defaults to directory of merged package named --into, or of first merged package)")
+	return c
+}
+
+// mergeDirectory picks directory of merged packages, selected package which already has target name takes precedence.
+func mergeDirectory(refs []packageRef, ids []int64, into string) string {
+	var first string
+	for _, ref := range refs {
+		if !slices.Contains(ids, ref.uuid) {
+			continue
+		}
+		if ref.name == into && len(ref.saveTo) > 0 {
+			return ref.saveTo
+		}
+		if len(first) == 0 {
+			first = ref.saveTo
+		}
+	}
+	return first
+}
+
+func newPackageSplitCommand(out io.Writer, target packageTarget) *cobra.Command {
+	return newPackageCommand(out, target, "split [PACKAGE...]", "Split packages by hoster",
+		cobra.ArbitraryArgs, leadingParams(0), func(ops packageOps, _ []packageRef, ids []int64, _ []string) error {
+			if err := ops.SplitPackageByHoster([]int64{}, ids); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%d packages split by hoster\n", len(ids))
+			return nil
+		})
+}
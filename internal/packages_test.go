@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPackageRefs(t *testing.T) {
+	refs := []packageRef{{uuid: 10, name: "movies"}, {uuid: 20, name: "music"}, {uuid: 30, name: "books"}}
+	ids, err := matchPackageRefs(refs, []string{"30", "movies"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{10, 30}, ids)
+	ids, err = matchPackageRefs(refs, nil, "^mu")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{20}, ids)
+	_, err = matchPackageRefs(refs, []string{"games"}, "")
+	assert.Error(t, err)
+}
+
+func TestMoveDestination(t *testing.T) {
+	refs := []packageRef{{uuid: 10}, {uuid: 20}, {uuid: 30}}
+	assert.Nil(t, moveDestination(refs, []int64{20}, true))
+	assert.Equal(t, int64(30), *moveDestination(refs, []int64{20}, false))
+	assert.Equal(t, int64(10), *moveDestination(refs, []int64{20, 30}, false))
+	assert.Nil(t, moveDestination(refs, []int64{10, 20, 30}, false))
+}
+
+func TestMergeDirectory(t *testing.T) {
+	refs := []packageRef{{uuid: 10, name: "a", saveTo: "/data/a"}, {uuid: 20, name: "b", saveTo: "/data/b"}, {uuid: 30}}
+	assert.Equal(t, "/data/b", mergeDirectory(refs, []int64{20, 30}, "all"))
+	assert.Equal(t, "/data/b", mergeDirectory(refs, []int64{10, 20}, "b"))
+	assert.Equal(t, "", mergeDirectory(refs, []int64{30}, "all"))
+}
+
+func TestPackageMergeRequiresName(t *testing.T) {
+	var errOut bytes.Buffer
+	code := Execute(context.Background(), []string{"download", "package", "merge", "a", "b"}, strings.NewReader(""), io.Discard, &errOut)
+	assert.Equal(t, exitCodeUsage, code)
+	assert.Contains(t, errOut.String(), "use --into")
+}
+
+func TestArgSplitters(t *testing.T) {
+	params, selectors := trailingParams(1)([]string{"old", "new"})
+	assert.Equal(t, []string{"new"}, params)
+	assert.Equal(t, []string{"old"}, selectors)
+	params, selectors = leadingParams(1)([]string{"high", "a", "b"})
+	assert.Equal(t, []string{"high"}, params)
+	assert.Equal(t, []string{"a", "b"}, selectors)
+}
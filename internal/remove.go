@@ -0,0 +1,358 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// removeSelector describes which links or packages are removed, all given conditions must match.
+type removeSelector struct {
+	ids       []int64
+	status    string
+	host      string
+	name      string
+	packages  []string
+	olderThan time.Duration
+	failed    bool
+	filter    string
+	yes       bool
+	dryRun    bool
+}
+
+func (s *removeSelector) empty() bool {
+	return len(s.ids) == 0 && len(s.status) == 0 && len(s.host) == 0 && len(s.name) == 0 &&
+		len(s.packages) == 0 && s.olderThan == 0 && !s.failed && len(strings.TrimSpace(s.filter)) == 0
+}
+
+func addRemoveSelectorFlags(fs *pflag.FlagSet, target *removeSelector, idHelp string) {
+	fs.Int64SliceVar(&target.ids, "id", target.ids, idHelp)
+	fs.StringVar(&target.status, "status", target.status, "Remove items with given status text (case-insensitive)")
+	fs.StringVar(&target.host, "host", target.host, "Remove items from hosts containing given text")
+	fs.StringVar(&target.name, "name", target.name, "Remove items with name matching regular expression")
+	fs.StringArrayVar(&target.packages, "package", target.packages, "Remove items of package given by UUID or name. Can be specified multiple times")
+	addFilterFlag(fs, &target.filter)
+	fs.BoolVarP(&target.yes, "yes", "y", target.yes, "Don't ask for confirmation")
+	fs.BoolVar(&target.dryRun, "dry-run", target.dryRun, "Only show what would be removed")
+}
+
+// addDownloadSelectorFlags registers selectors which are only applicable to download list.
+func addDownloadSelectorFlags(fs *pflag.FlagSet, target *removeSelector) {
+	fs.DurationVar(&target.olderThan, "older-than", target.olderThan, "Remove items added more than given duration ago")
+	fs.BoolVar(&target.failed, "failed", target.failed, "Remove failed, offline or skipped items")
+}
+
+func newDownloadLinkRmCommand(out io.Writer) *cobra.Command {
+	type rmData struct {
+		sel removeSelector
+	}
+	var data rmData
+	data.sel.ids = make([]int64, 0)
+	c := &cobra.Command{
+		Use:   "rm [UUID...|-]",
+		Short: "Remove links selected by identifiers or filters",
+		Long: `Remove links selected by identifiers or filters, all given conditions must match.
+Identifiers can be given as arguments or read from standard input when argument is '-'.
+Matching links are shown and removal has to be confirmed, unless --yes is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdin, err := readIdArgs(args, cmd.InOrStdin(), &data.sel.ids)
+			if err != nil {
+				return err
+			}
+			if data.sel.empty() {
+				return withExitCode(exitCodeUsage, errors.New("no link identifier(s) or selector was specified (use --id id1 --id id2 ..., --filter, ...)"))
+			}
+			if stdin && !data.sel.yes && !data.sel.dryRun {
+				return errors.New("--yes is required when identifiers are read from standard input")
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				links, err := dl.Links()
+				if err != nil {
+					return err
+				}
+				pkgs, err := dl.Packages()
+				if err != nil {
+					return err
+				}
+				matched, err := selectLinks(*links, *pkgs, &data.sel, time.Now())
+				if err != nil {
+					return err
+				}
+				ok, err := confirmRemoval(cmd.InOrStdin(), out, &data.sel, matched, dlCols, "links")
+				if err != nil || !ok {
+					return err
+				}
+				ids := make([]int64, 0, len(matched))
+				for _, link := range matched {
+					ids = append(ids, *link.Uuid)
+				}
+				if err = dl.Remove(ids, []int64{}); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%d links removed\n", len(ids))
+				return nil
+			})
+		},
+	}
+	addRemoveSelectorFlags(c.Flags(), &data.sel, "Link identifier. Can be specified multiple times")
+	addDownloadSelectorFlags(c.Flags(), &data.sel)
+	return c
+}
+
+func newDownloadPackageRmCommand(out io.Writer) *cobra.Command {
+	type rmData struct {
+		sel removeSelector
+	}
+	var data rmData
+	c := &cobra.Command{
+		Use:   "rm [UUID...|-]",
+		Short: "Remove packages selected by identifiers or filters",
+		Long: `Remove packages (including all their links) selected by identifiers or filters, all given conditions must match.
+Host, --older-than and --failed conditions are evaluated against links of package: package matches host when any
+of its links does, it is older when all its links are and it has failed when none of its links can progress
+and some of them failed. Identifiers can be given as arguments or read from standard input when argument is '-'.
+Matching packages are shown and removal has to be confirmed, unless --yes is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdin, err := readIdArgs(args, cmd.InOrStdin(), &data.sel.ids)
+			if err != nil {
+				return err
+			}
+			if data.sel.empty() {
+				return withExitCode(exitCodeUsage, errors.New("no package identifier(s) or selector was specified (use --id id1 --id id2 ..., --filter, ...)"))
+			}
+			if stdin && !data.sel.yes && !data.sel.dryRun {
+				return errors.New("--yes is required when identifiers are read from standard input")
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				pkgs, err := dl.Packages()
+				if err != nil {
+					return err
+				}
+				links, err := dl.Links()
+				if err != nil {
+					return err
+				}
+				matched, err := selectPackages(*pkgs, *links, &data.sel, time.Now())
+				if err != nil {
+					return err
+				}
+				ok, err := confirmRemoval(cmd.InOrStdin(), out, &data.sel, matched, pkgCols, "packages")
+				if err != nil || !ok {
+					return err
+				}
+				ids := make([]int64, 0, len(matched))
+				for _, pkg := range matched {
+					ids = append(ids, *pkg.Uuid)
+				}
+				if err = dl.Remove([]int64{}, ids); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%d packages removed\n", len(ids))
+				return nil
+			})
+		},
+	}
+	addRemoveSelectorFlags(c.Flags(), &data.sel, "Package identifier. Can be specified multiple times")
+	addDownloadSelectorFlags(c.Flags(), &data.sel)
+	return c
+}
+
+// readIdArgs appends identifiers given as arguments to ids, argument '-' reads whitespace separated identifiers from in.
+// It reports whether identifiers were read from in.
+func readIdArgs(args []string, in io.Reader, ids *[]int64) (bool, error) {
+	stdin := false
+	for _, arg := range args {
+		if arg != "-" {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return stdin, fmt.Errorf("invalid identifier '%s'", arg)
+			}
+			*ids = append(*ids, id)
+			continue
+		}
+		if stdin {
+			continue
+		}
+		stdin = true
+		sc := bufio.NewScanner(in)
+		sc.Split(bufio.ScanWords)
+		for sc.Scan() {
+			id, err := strconv.ParseInt(sc.Text(), 10, 64)
+			if err != nil {
+				return stdin, fmt.Errorf("invalid identifier '%s' on standard input", sc.Text())
+			}
+			*ids = append(*ids, id)
+		}
+		if err := sc.Err(); err != nil {
+			return stdin, err
+		}
+	}
+	return stdin, nil
+}
+
+// selectLinks returns links matching all conditions of selector.
+func selectLinks(links []jdownloader.DownloadLink, pkgs []jdownloader.FilePackage, sel *removeSelector, now time.Time) ([]jdownloader.DownloadLink, error) {
+	nameRe, err := compileNameRe(sel.name)
+	if err != nil {
+		return nil, err
+	}
+	var pkgIds map[int64]bool
+	if len(sel.packages) > 0 {
+		if pkgIds, err = matchPackages(pkgs, sel.packages); err != nil {
+			return nil, err
+		}
+	}
+	if links, err = filterList(links, sel.filter); err != nil {
+		return nil, err
+	}
+	res := make([]jdownloader.DownloadLink, 0)
+	for _, link := range links {
+		switch {
+		case link.Uuid == nil:
+		case len(sel.ids) > 0 && !slices.Contains(sel.ids, *link.Uuid):
+		case len(sel.status) > 0 && !strings.EqualFold(strVal(link.Status), sel.status):
+		case !matchHost(strVal(link.Host), sel.host):
+		case nameRe != nil && !nameRe.MatchString(strVal(link.Name)):
+		case pkgIds != nil && (link.PackageUuid == nil || !pkgIds[*link.PackageUuid]):
+		case sel.olderThan > 0 && !addedBefore(link, now.Add(-sel.olderThan)):
+		case sel.failed && !isLinkFailed(link):
+		default:
+			res = append(res, link)
+		}
+	}
+	return res, nil
+}
+
+// selectPackages returns packages matching all conditions of selector, see newDownloadPackageRmCommand for semantics.
+func selectPackages(pkgs []jdownloader.FilePackage, links []jdownloader.DownloadLink, sel *removeSelector, now time.Time) ([]jdownloader.FilePackage, error) {
+	nameRe, err := compileNameRe(sel.name)
+	if err != nil {
+		return nil, err
+	}
+	var pkgIds map[int64]bool
+	if len(sel.packages) > 0 {
+		if pkgIds, err = matchPackages(pkgs, sel.packages); err != nil {
+			return nil, err
+		}
+	}
+	if pkgs, err = filterList(pkgs, sel.filter); err != nil {
+		return nil, err
+	}
+	children := make(map[int64][]jdownloader.DownloadLink)
+	for _, link := range links {
+		if link.PackageUuid != nil {
+			children[*link.PackageUuid] = append(children[*link.PackageUuid], link)
+		}
+	}
+	res := make([]jdownloader.FilePackage, 0)
+	for _, pkg := range pkgs {
+		if pkg.Uuid == nil {
+			continue
+		}
+		pkgLinks := children[*pkg.Uuid]
+		switch {
+		case len(sel.ids) > 0 && !slices.Contains(sel.ids, *pkg.Uuid):
+		case len(sel.status) > 0 && !strings.EqualFold(strVal(pkg.Status), sel.status):
+		case len(sel.host) > 0 && !slices.ContainsFunc(pkgLinks, func(l jdownloader.DownloadLink) bool {
+			return matchHost(strVal(l.Host), sel.host)
+		}):
+		case nameRe != nil && !nameRe.MatchString(strVal(pkg.Name)):
+		case pkgIds != nil && !pkgIds[*pkg.Uuid]:
+		case sel.olderThan > 0 && (len(pkgLinks) == 0 || slices.ContainsFunc(pkgLinks, func(l jdownloader.DownloadLink) bool {
+			return !addedBefore(l, now.Add(-sel.olderThan))
+		})):
+		case sel.failed && !isPackageFailed(*pkg.Uuid, pkgLinks):
+		default:
+			res = append(res, pkg)
+		}
+	}
+	return res, nil
+}
+
+// isPackageFailed tells whether none of package links can progress anymore, while some of them failed.
+func isPackageFailed(uuid int64, links []jdownloader.DownloadLink) bool {
+	p := waitForLinks(links, map[int64]bool{uuid: true}, nil)
+	return p.total > 0 && p.done() && p.failed > 0
+}
+
+func compileNameRe(expr string) (*regexp.Regexp, error) {
+	if len(expr) == 0 {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name expression: %v", err)
+	}
+	return re, nil
+}
+
+func matchHost(host, sel string) bool {
+	return strings.Contains(strings.ToLower(host), strings.ToLower(sel))
+}
+
+func addedBefore(link jdownloader.DownloadLink, t time.Time) bool {
+	return link.AddedDate != nil && *link.AddedDate > 0 && time.UnixMilli(*link.AddedDate).Before(t)
+}
+
+// confirmRemoval prints matched items and asks user to confirm their removal.
+// It returns false when there is nothing to remove, when run is dry or when user declined.
+func confirmRemoval[T any](in io.Reader, out io.Writer, sel *removeSelector, items []T, cols []column[T], kind string) (bool, error) {
+	if len(items) == 0 {
+		fmt.Fprintf(out, "No matching %s\n", kind)
+		return false, nil
+	}
+	if err := printTable(out, items, cols, false); err != nil {
+		return false, err
+	}
+	if sel.dryRun {
+		fmt.Fprintf(out, "%d %s would be removed\n", len(items), kind)
+		return false, nil
+	}
+	if sel.yes {
+		return true, nil
+	}
+	ok, err := confirm(in, out, fmt.Sprintf("Remove %d %s?", len(items), kind))
+	if err == nil && !ok {
+		fmt.Fprintf(out, "Removal cancelled\n")
+	}
+	return ok, err
+}
+
+// confirm asks yes/no question, anything but "y" or "yes" (including end of input) is treated as no.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadIdArgs(t *testing.T) {
+	ids := []int64{1}
+	stdin, err := readIdArgs([]string{"2", "-"}, strings.NewReader("3\n4 5\n"), &ids)
+	assert.NoError(t, err)
+	assert.True(t, stdin)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+	_, err = readIdArgs([]string{"abc"}, nil, &ids)
+	assert.Error(t, err)
+}
+
+func TestSelectLinks(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-48 * time.Hour).UnixMilli()
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Name: pstr("a.mkv"), Host: pstr("rapidgator.net"), AddedDate: &old},
+		{Uuid: pint64(2), PackageUuid: pint64(10), Name: pstr("b.zip"), Host: pstr("mega.nz"), StatusIconKey: pstr("false")},
+		{Uuid: pint64(3), PackageUuid: pint64(20), Name: pstr("c.mkv"), Host: pstr("mega.nz"), Status: pstr("Finished")},
+	}
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("movies")}, {Uuid: pint64(20), Name: pstr("music")}}
+
+	res, err := selectLinks(links, pkgs, &removeSelector{name: `\.mkv$`, host: "MEGA"}, now)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, int64(3), *res[0].Uuid)
+
+	res, err = selectLinks(links, pkgs, &removeSelector{packages: []string{"movies"}, failed: true}, now)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, int64(2), *res[0].Uuid)
+
+	res, err = selectLinks(links, pkgs, &removeSelector{olderThan: 24 * time.Hour}, now)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, int64(1), *res[0].Uuid)
+
+	res, err = selectLinks(links, pkgs, &removeSelector{status: "finished", ids: []int64{1, 3}}, now)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	_, err = selectLinks(links, pkgs, &removeSelector{name: "("}, now)
+	assert.Error(t, err)
+}
+
+func TestSelectPackages(t *testing.T) {
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Finished: pbool(true), Host: pstr("mega.nz")},
+		{Uuid: pint64(2), PackageUuid: pint64(10), StatusIconKey: pstr("false"), Host: pstr("mega.nz")},
+		{Uuid: pint64(3), PackageUuid: pint64(20), Host: pstr("rapidgator.net")},
+	}
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("movies")}, {Uuid: pint64(20), Name: pstr("music")}}
+
+	res, err := selectPackages(pkgs, links, &removeSelector{failed: true}, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "movies", *res[0].Name)
+
+	res, err = selectPackages(pkgs, links, &removeSelector{host: "rapidgator"}, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "music", *res[0].Name)
+
+	res, err = selectPackages(pkgs, links, &removeSelector{filter: "name~mus"}, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+}
+
+func TestConfirmRemoval(t *testing.T) {
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("movies")}}
+	var out bytes.Buffer
+	ok, err := confirmRemoval(strings.NewReader("y\n"), &out, &removeSelector{}, pkgs, pkgCols, "packages")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Contains(t, out.String(), "Remove 1 packages? [y/N]")
+
+	ok, err = confirmRemoval(strings.NewReader(""), &out, &removeSelector{}, pkgs, pkgCols, "packages")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	out.Reset()
+	ok, err = confirmRemoval(nil, &out, &removeSelector{dryRun: true}, pkgs, pkgCols, "packages")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "1 packages would be removed")
+}
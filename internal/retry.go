@@ -0,0 +1,414 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+)
+
+const (
+	defaultApiRetries    = 3
+	defaultApiBackoff    = time.Second
+	defaultApiMaxBackoff = 30 * time.Second
+)
+
+var (
+	// retryableApiErrors are MyJDownloader error types of transient failures.
+	retryableApiErrors = []string{"OVERLOAD", "TOO_MANY_REQUESTS", "MAINTENANCE", "OFFLINE", "INTERNAL_SERVER_ERROR"}
+	// authApiErrors are never retried, repeating them would only get account locked.
+	authApiErrors = []string{"AUTH_FAILED", "TOKEN_INVALID", "EMAIL_INVALID", "EMAIL_FORBIDDEN",
+		"ERROR_EMAIL_NOT_CONFIRMED", "CHALLENGE_FAILED"}
+	// sessionApiErrors mean that session tokens are no longer accepted, new login is needed.
+	sessionApiErrors  = []string{"TOKEN_INVALID"}
+	retryableStatusRe = regexp.MustCompile(`(?i)(status|code|http)\D{0,3}(429|5\d\d)\b`)
+)
+
+// apiConfig tunes timeouts and retries of API calls, flags take precedence over config file.
+type apiConfig struct {
+	Timeout    *time.Duration `yaml:"timeout,omitempty"`
+	Retries    *int           `yaml:"retries,omitempty"`
+	Backoff    *time.Duration `yaml:"backoff,omitempty"`
+	MaxBackoff *time.Duration `yaml:"max-backoff,omitempty"`
+}
+
+// retryPolicy repeats failed API calls with exponential backoff as long as their errors are retryable.
+// Call rejected because of invalid session is repeated once after relogin, when it is set.
+// Calls and delays between them are abandoned once ctx is cancelled.
+type retryPolicy struct {
+	ctx        context.Context
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	logger     *slog.Logger
+	sleep      func(context.Context, time.Duration) error
+	relogin    func(ctx context.Context) error
+}
+
+type callResult[T any] struct {
+	val T
+	err error
+}
+
+func isAuthError(err error) bool {
+	return containsApiError(err, authApiErrors)
+}
+
+func isSessionError(err error) bool {
+	return err != nil && containsApiError(err, sessionApiErrors)
+}
+
+// isRetryable tells whether err is transient: network failure, server error, rate limit or device being offline.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || isAuthError(err) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	return containsApiError(err, retryableApiErrors) || retryableStatusRe.MatchString(err.Error())
+}
+
+func containsApiError(err error, types []string) bool {
+	msg := strings.ToUpper(err.Error())
+	for _, t := range types {
+		if strings.Contains(msg, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns backoff before given retry, half of it is random so that clients don't retry in lockstep.
+func (p *retryPolicy) delay(attempt int) time.Duration {
+	d := p.maxBackoff
+	if attempt < 32 {
+		if exp := p.backoff << attempt; exp > 0 && exp < d {
+			d = exp
+		}
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func (p *retryPolicy) do(call string, fn func() error) error {
+	_, err := retryCall(p, call, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// once calls fn without retrying it, for calls which are not safe to repeat.
+// Call rejected because of invalid session was not performed, so it is still repeated after relogin.
+func (p *retryPolicy) once(call string, fn func() error) error {
+	_, err := callSession(p, call, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// callSession calls fn, when server rejects session, it logs in again and repeats the call once.
+func callSession[T any](p *retryPolicy, call string, fn func() (T, error)) (T, error) {
+	res, err := callCtx(p.ctx, fn)
+	if !isSessionError(err) || p.relogin == nil {
+		return res, err
+	}
+	p.logger.Debug("session was rejected, logging in again", "call", call, "error", err)
+	if err = p.relogin(p.ctx); err != nil {
+		return res, err
+	}
+	return callCtx(p.ctx, fn)
+}
+
+func retryCall[T any](p *retryPolicy, call string, fn func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := callSession(p, call, fn)
+		if attempt >= p.retries || !isRetryable(err) {
+			return res, err
+		}
+		d := p.delay(attempt)
+		p.logger.Debug("API call failed, retrying", "call", call, "attempt", attempt+1, "delay", d, "error", err)
+		if err = p.sleep(p.ctx, d); err != nil {
+			return res, err
+		}
+	}
+}
+
+// callCtx runs fn in background, so that caller can return as soon as ctx is cancelled.
+// Library calls can't be interrupted otherwise, abandoned call finishes on its own.
+func callCtx[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	done := make(chan callResult[T], 1)
+	go func() {
+		val, err := fn()
+		done <- callResult[T]{val: val, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.val, res.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// sleepCtx waits for given duration, unless ctx is cancelled sooner.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryingClient applies retryPolicy to client calls and to calls of devices it returns.
+type retryingClient struct {
+	jdownloader.JdClient
+	p *retryPolicy
+}
+
+// withContext returns copy of client whose calls, including calls of devices it returns, are bound to ctx.
+func (c *retryingClient) withContext(ctx context.Context) *retryingClient {
+	p := *c.p
+	p.ctx = ctx
+	return &retryingClient{JdClient: c.JdClient, p: &p}
+}
+
+func (c *retryingClient) Connect() error {
+	return c.p.do("connect", c.JdClient.Connect)
+}
+
+func (c *retryingClient) ListDevices() (*[]jdownloader.DeviceInfo, error) {
+	return retryCall(c.p, "list devices", c.JdClient.ListDevices)
+}
+
+func (c *retryingClient) Device(name string) (jdownloader.Device, error) {
+	dev, err := retryCall(c.p, "device", func() (jdownloader.Device, error) {
+		return c.JdClient.Device(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &retryingDevice{Device: dev, p: c.p}, nil
+}
+
+type retryingDevice struct {
+	jdownloader.Device
+	p *retryPolicy
+}
+
+func (d *retryingDevice) Downloader() jdownloader.Downloader {
+	return &retryingDownloader{Downloader: d.Device.Downloader(), p: d.p}
+}
+
+func (d *retryingDevice) LinkGrabber() jdownloader.LinkGrabber {
+	return &retryingLinkGrabber{LinkGrabber: d.Device.LinkGrabber(), p: d.p}
+}
+
+// retryingDownloader retries reads and idempotent changes, MoveToNewPackage and SplitPackageByHoster
+// are called only once as repeating them could create extra packages.
+type retryingDownloader struct {
+	jdownloader.Downloader
+	p *retryPolicy
+}
+
+func (d *retryingDownloader) Links() (*[]jdownloader.DownloadLink, error) {
+	return retryCall(d.p, "download links", d.Downloader.Links)
+}
+
+func (d *retryingDownloader) Packages() (*[]jdownloader.FilePackage, error) {
+	return retryCall(d.p, "download packages", d.Downloader.Packages)
+}
+
+func (d *retryingDownloader) Speed() (*jdownloader.SpeedInfo, error) {
+	return retryCall(d.p, "download speed", d.Downloader.Speed)
+}
+
+func (d *retryingDownloader) State() (*jdownloader.DownloaderState, error) {
+	return retryCall(d.p, "download state", d.Downloader.State)
+}
+
+func (d *retryingDownloader) Remove(linkIds []int64, pkgIds []int64) error {
+	return d.p.do("remove downloads", func() error {
+		return d.Downloader.Remove(linkIds, pkgIds)
+	})
+}
+
+func (d *retryingDownloader) Start() (bool, error) {
+	return retryCall(d.p, "start downloads", d.Downloader.Start)
+}
+
+func (d *retryingDownloader) Stop() (bool, error) {
+	return retryCall(d.p, "stop downloads", d.Downloader.Stop)
+}
+
+func (d *retryingDownloader) Pause() (bool, error) {
+	return retryCall(d.p, "pause downloads", d.Downloader.Pause)
+}
+
+func (d *retryingDownloader) RenamePackage(pkgId int64, name string) error {
+	return d.p.do("rename package", func() error {
+		return d.Downloader.RenamePackage(pkgId, name)
+	})
+}
+
+func (d *retryingDownloader) SetDownloadDirectory(dir string, pkgIds []int64) error {
+	return d.p.do("set download directory", func() error {
+		return d.Downloader.SetDownloadDirectory(dir, pkgIds)
+	})
+}
+
+func (d *retryingDownloader) SetPriority(priority string, linkIds []int64, pkgIds []int64) error {
+	return d.p.do("set priority", func() error {
+		return d.Downloader.SetPriority(priority, linkIds, pkgIds)
+	})
+}
+
+func (d *retryingDownloader) SetEnabled(enabled bool, linkIds []int64, pkgIds []int64) error {
+	return d.p.do("set enabled", func() error {
+		return d.Downloader.SetEnabled(enabled, linkIds, pkgIds)
+	})
+}
+
+func (d *retryingDownloader) MovePackages(pkgIds []int64, afterDest *int64) error {
+	return d.p.do("move packages", func() error {
+		return d.Downloader.MovePackages(pkgIds, afterDest)
+	})
+}
+
+func (d *retryingDownloader) MoveToNewPackage(linkIds []int64, pkgIds []int64, name string, dir string) error {
+	return d.p.once("move to new package", func() error {
+		return d.Downloader.MoveToNewPackage(linkIds, pkgIds, name, dir)
+	})
+}
+
+func (d *retryingDownloader) SplitPackageByHoster(linkIds []int64, pkgIds []int64) error {
+	return d.p.once("split package by hoster", func() error {
+		return d.Downloader.SplitPackageByHoster(linkIds, pkgIds)
+	})
+}
+
+func (d *retryingDownloader) ExportDLC(linkIds []int64, pkgIds []int64) ([]byte, error) {
+	return retryCall(d.p, "export dlc", func() ([]byte, error) {
+		return d.Downloader.ExportDLC(linkIds, pkgIds)
+	})
+}
+
+// retryingLinkGrabber retries reads and idempotent changes, adding links or containers and changes
+// creating packages are called only once as repeated call could add them twice.
+type retryingLinkGrabber struct {
+	jdownloader.LinkGrabber
+	p *retryPolicy
+}
+
+func (l *retryingLinkGrabber) Add(links []string, opts ...jdownloader.AddLinksOptions) (*jdownloader.Response, error) {
+	return callSession(l.p, "add links", func() (*jdownloader.Response, error) {
+		return l.LinkGrabber.Add(links, opts...)
+	})
+}
+
+func (l *retryingLinkGrabber) AddContainer(containerType string, content []byte) error {
+	return l.p.once("add container", func() error {
+		return l.LinkGrabber.AddContainer(containerType, content)
+	})
+}
+
+func (l *retryingLinkGrabber) Links() (*[]jdownloader.CrawledLink, error) {
+	return retryCall(l.p, "collector links", l.LinkGrabber.Links)
+}
+
+func (l *retryingLinkGrabber) Packages() (*[]jdownloader.CrawledPackage, error) {
+	return retryCall(l.p, "collector packages", l.LinkGrabber.Packages)
+}
+
+func (l *retryingLinkGrabber) QueryCrawlerJobs(jobIds []int64) (*[]jdownloader.LinkCrawlerJob, error) {
+	return retryCall(l.p, "query crawler jobs", func() (*[]jdownloader.LinkCrawlerJob, error) {
+		return l.LinkGrabber.QueryCrawlerJobs(jobIds)
+	})
+}
+
+func (l *retryingLinkGrabber) MoveToDownloadlist(linkIds []int64, pkgIds []int64) error {
+	return l.p.do("move to download list", func() error {
+		return l.LinkGrabber.MoveToDownloadlist(linkIds, pkgIds)
+	})
+}
+
+func (l *retryingLinkGrabber) RemoveLinks(linkIds []int64, pkgIds []int64) error {
+	return l.p.do("remove collector links", func() error {
+		return l.LinkGrabber.RemoveLinks(linkIds, pkgIds)
+	})
+}
+
+func (l *retryingLinkGrabber) ClearList() error {
+	return l.p.do("clear collector", l.LinkGrabber.ClearList)
+}
+
+func (l *retryingLinkGrabber) RenamePackage(pkgId int64, name string) error {
+	return l.p.do("rename package", func() error {
+		return l.LinkGrabber.RenamePackage(pkgId, name)
+	})
+}
+
+func (l *retryingLinkGrabber) SetDownloadDirectory(dir string, pkgIds []int64) error {
+	return l.p.do("set download directory", func() error {
+		return l.LinkGrabber.SetDownloadDirectory(dir, pkgIds)
+	})
+}
+
+func (l *retryingLinkGrabber) SetPriority(priority string, linkIds []int64, pkgIds []int64) error {
+	return l.p.do("set priority", func() error {
+		return l.LinkGrabber.SetPriority(priority, linkIds, pkgIds)
+	})
+}
+
+func (l *retryingLinkGrabber) SetEnabled(enabled bool, linkIds []int64, pkgIds []int64) error {
+	return l.p.do("set enabled", func() error {
+		return l.LinkGrabber.SetEnabled(enabled, linkIds, pkgIds)
+	})
+}
+
+func (l *retryingLinkGrabber) MovePackages(pkgIds []int64, afterDest *int64) error {
+	return l.p.do("move packages", func() error {
+		return l.LinkGrabber.MovePackages(pkgIds, afterDest)
+	})
+}
+
+func (l *retryingLinkGrabber) MoveToNewPackage(linkIds []int64, pkgIds []int64, name string, dir string) error {
+	return l.p.once("move to new package", func() error {
+		return l.LinkGrabber.MoveToNewPackage(linkIds, pkgIds, name, dir)
+	})
+}
+
+func (l *retryingLinkGrabber) SplitPackageByHoster(linkIds []int64, pkgIds []int64) error {
+	return l.p.once("split package by hoster", func() error {
+		return l.LinkGrabber.SplitPackageByHoster(linkIds, pkgIds)
+	})
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for err, expected := range map[error]bool{
+		&net.OpError{Op: "dial", Err: errors.New("connection refused")}: true,
+		fmt.Errorf("read: %w", io.ErrUnexpectedEOF):                     true,
+		errors.New("unexpected status code: 503"):                       true,
+		errors.New("HTTP 429 Too Many Requests"):                        true,
+		errors.New("api error: OVERLOAD"):                               true,
+		errors.New("device is offline"):                                 true,
+		errors.New("api error: AUTH_FAILED"):                            false,
+		errors.New("TOKEN_INVALID (status code 503)"):                   false,
+		errors.New("package 503 not found"):                             false,
+		context.Canceled:                                                false,
+	} {
+		assert.Equal(t, expected, isRetryable(err), err.Error())
+	}
+	assert.False(t, isRetryable(nil))
+}
+
+func testRetryPolicy(retries int, slept *[]time.Duration) *retryPolicy {
+	return &retryPolicy{
+		ctx:        context.Background(),
+		retries:    retries,
+		backoff:    time.Second,
+		maxBackoff: 5 * time.Second,
+		logger:     newCliContext(nil, nil, nil).getLogger(),
+		sleep: func(_ context.Context, d time.Duration) error {
+			*slept = append(*slept, d)
+			return nil
+		},
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := testRetryPolicy(0, nil)
+	for attempt, limit := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		for range 20 {
+			d := p.delay(attempt)
+			assert.GreaterOrEqual(t, d, limit/2)
+			assert.LessOrEqual(t, d, limit)
+		}
+	}
+	assert.LessOrEqual(t, p.delay(100), 5*time.Second)
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	var slept []time.Duration
+	p := testRetryPolicy(3, &slept)
+	calls := 0
+	res, err := retryCall(p, "test", func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("MAINTENANCE")
+		}
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, res)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, slept, 2)
+
+	calls, slept = 0, nil
+	assert.Error(t, p.do("test", func() error {
+		calls++
+		return errors.New("OVERLOAD")
+	}))
+	assert.Equal(t, 4, calls)
+	assert.Len(t, slept, 3)
+
+	calls, slept = 0, nil
+	assert.Error(t, p.do("test", func() error {
+		calls++
+		return errors.New("AUTH_FAILED")
+	}))
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, slept)
+}
+
+func TestRetryPolicyCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := testRetryPolicy(3, nil)
+	p.ctx, p.sleep = ctx, sleepCtx
+	block := make(chan struct{})
+	defer close(block)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := p.do("test", func() error {
+		<-block
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	calls := 0
+	p.ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	p.backoff, p.maxBackoff = time.Hour, time.Hour
+	assert.ErrorIs(t, p.do("test", func() error {
+		calls++
+		return errors.New("OVERLOAD")
+	}), context.DeadlineExceeded)
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, p.do("test", func() error {
+		calls++
+		return nil
+	}), context.DeadlineExceeded)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyRelogin(t *testing.T) {
+	var slept []time.Duration
+	p := testRetryPolicy(3, &slept)
+	logins := 0
+	p.relogin = func(context.Context) error {
+		logins++
+		return nil
+	}
+	calls := 0
+	res, err := retryCall(p, "test", func() (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("api error: TOKEN_INVALID")
+		}
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, res)
+	assert.Equal(t, 1, logins)
+	assert.Empty(t, slept)
+
+	calls, logins = 0, 0
+	assert.Error(t, p.once("test", func() error {
+		calls++
+		return errors.New("TOKEN_INVALID")
+	}))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, logins)
+
+	calls = 0
+	p.relogin = func(context.Context) error {
+		return errors.New("AUTH_FAILED")
+	}
+	assert.EqualError(t, p.do("test", func() error {
+		calls++
+		return errors.New("TOKEN_INVALID")
+	}), "AUTH_FAILED")
+	assert.Equal(t, 1, calls)
+}
+
+type flakyDownloader struct {
+	jdownloader.Downloader
+	failures int
+}
+
+func (f *flakyDownloader) Packages() (*[]jdownloader.FilePackage, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("OFFLINE")
+	}
+	return &[]jdownloader.FilePackage{{Uuid: pint64(1)}}, nil
+}
+
+func TestRetryingDownloader(t *testing.T) {
+	var slept []time.Duration
+	dl := &retryingDownloader{Downloader: &flakyDownloader{failures: 2}, p: testRetryPolicy(2, &slept)}
+	pkgs, err := dl.Packages()
+	assert.NoError(t, err)
+	assert.Len(t, *pkgs, 1)
+
+	dl = &retryingDownloader{Downloader: &flakyDownloader{failures: 2}, p: testRetryPolicy(1, &slept)}
+	_, err = dl.Packages()
+	assert.Error(t, err)
+}
+
+func TestApplyApiConfig(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	var cfg configData
+	assert.NoError(t, yaml.Unmarshal([]byte("api:\n  timeout: 10s\n  retries: 5\n  backoff: 500ms\n  max-backoff: 1m\n"), &cfg))
+
+	cc := newCliContext(nil, nil, nil)
+	assert.NoError(t, cc.applyConfig(cfg.Api))
+	assert.Equal(t, 10*time.Second, cc.timeout)
+	assert.Equal(t, 5, cc.retries)
+	assert.Equal(t, 500*time.Millisecond, cc.backoff)
+	assert.Equal(t, time.Minute, cc.maxBackoff)
+
+	root := NewRootCommand(nil, io.Discard, io.Discard)
+	root.SetArgs([]string{"version", "--retries", "1"})
+	assert.NoError(t, root.Execute())
+	version, _, err := root.Find([]string{"version"})
+	assert.NoError(t, err)
+	cc = cliContextOf(version)
+	assert.NoError(t, cc.applyConfig(cfg.Api))
+	assert.Equal(t, 1, cc.retries)
+	assert.Equal(t, 10*time.Second, cc.timeout)
+
+	*cfg.Api.Backoff = 2 * time.Minute
+	assert.Error(t, cc.applyConfig(cfg.Api))
+	assert.NoError(t, saveConfig(&cfg))
+	saved, err := readConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Api, saved.Api)
+}
+
+type expiredSessionLinkGrabber struct {
+	jdownloader.LinkGrabber
+	calls int
+}
+
+func (e *expiredSessionLinkGrabber) Add([]string, ...jdownloader.AddLinksOptions) (*jdownloader.Response, error) {
+	e.calls++
+	if e.calls == 1 {
+		return nil, errors.New("TOKEN_INVALID")
+	}
+	return &jdownloader.Response{}, nil
+}
+
+func TestRetryingLinkGrabberAddRelogin(t *testing.T) {
+	p := testRetryPolicy(3, nil)
+	logins := 0
+	p.relogin = func(context.Context) error {
+		logins++
+		return nil
+	}
+	lg := &expiredSessionLinkGrabber{}
+	_, err := (&retryingLinkGrabber{LinkGrabber: lg, p: p}).Add([]string{"https://example.com/a.zip"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lg.calls)
+	assert.Equal(t, 1, logins)
+}
@@ -17,22 +17,70 @@ limitations under the License.
 package internal
 
 import (
+	"context"
+	"errors"
 	"io"
 
 	"github.com/spf13/cobra"
 )
 
 func NewRootCommand(in io.Reader, out, err io.Writer) *cobra.Command {
+	return newRootCommand(newCliContext(in, out, err))
+}
+
+// Execute runs command given by args until it finishes or until ctx is cancelled and returns process exit code.
+// Client is disconnected in both cases, interruption is reported by exit code 130.
+// Failure is reported on errOut, as single line or as JSON object when JSON output is requested.
+func Execute(ctx context.Context, args []string, in io.Reader, out, errOut io.Writer) int {
+	cc := newCliContext(in, out, errOut)
+	root := newRootCommand(cc)
+	root.SetArgs(args)
+	cmd, err := root.ExecuteContextC(ctx)
+	interrupted := ctx.Err() != nil
+	cc.close(interrupted)
+	switch {
+	case interrupted:
+		err = withExitCode(exitCodeInterrupted, errors.New("interrupted"))
+	case err == nil:
+		return 0
+	case !cc.started:
+		// command didn't get to run, so arguments or flags were wrong
+		err = withExitCode(exitCodeUsage, err)
+	}
+	code := classifyError(err)
+	reportError(errOut, cc.output, cmd, code, err)
+	return code
+}
+
+func newRootCommand(cc *cliContext) *cobra.Command {
+	in, out := cc.in, cc.out
 	c := &cobra.Command{
-		Use:   "jdcli",
-		Short: "jDownloader CLI tool",
+		Use:           "jdcli",
+		Short:         "jDownloader CLI tool",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := cc.validate(); err != nil {
+				return err
+			}
+			cc.started = true
+			cc.attach(cmd)
+			return nil
+		},
 	}
 	c.ResetFlags()
+	c.SetIn(in)
+	c.SetErr(cc.errOut)
+	cc.addFlags(c.PersistentFlags())
+	c.AddCommand(newConfigCommand(out))
 	c.AddCommand(newLoginCommand(in, out))
-	c.AddCommand(newLogoutCommand())
+	c.AddCommand(newLogoutCommand(out))
 	c.AddCommand(newLinksCommand(out))
 	c.AddCommand(newDownloadsCommand(out))
 	c.AddCommand(newDeviceCommand(out))
+	c.AddCommand(newSessionCommand(out))
+	c.AddCommand(newExporterCommand(out))
+	c.AddCommand(newNotifyCommand(out))
 	c.AddCommand(newVersionCommand(out))
 	return c
 }
@@ -0,0 +1,258 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+const (
+	secretStorePlain   = "plain"
+	secretStoreKeyring = "keyring"
+	secretStorePass    = "pass"
+	secretStoreFile    = "file"
+
+	secretsFileName    = "jdsecrets.enc"
+	secretsPassEnvName = "JD_SECRETS_PASSPHRASE"
+	secretKeyPrefix    = "jdcli"
+	pbkdf2Iterations   = 600000
+)
+
+var secretStores = []string{secretStorePlain, secretStoreKeyring, secretStorePass, secretStoreFile}
+
+// secretStore keeps account passwords outside of config file, keyed by context name.
+type secretStore interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
+
+func newSecretStore(kind string) (secretStore, error) {
+	switch kind {
+	case secretStoreKeyring:
+		return &keyringStore{}, nil
+	case secretStorePass:
+		return &passStore{}, nil
+	case secretStoreFile:
+		cfgPath, err := getConfigPath()
+		if err != nil {
+			return nil, err
+		}
+		return &fileStore{
+			path:       filepath.Join(filepath.Dir(cfgPath), secretsFileName),
+			passphrase: sync.OnceValues(readSecretsPassphrase),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported secret store '%s', must be one of: %s", kind, strings.Join(secretStores, "|"))
+	}
+}
+
+func isPlainSecretStore(kind string) bool {
+	return len(kind) == 0 || kind == secretStorePlain
+}
+
+func runSecretCommand(stdin string, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// keyringStore uses Secret Service API (GNOME Keyring, KWallet, KeePassXC) via secret-tool.
+type keyringStore struct{}
+
+func (k *keyringStore) Get(key string) (string, error) {
+	res, err := runSecretCommand("", "secret-tool", "lookup", "service", secretKeyPrefix, "account", key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(res, "\n"), nil
+}
+
+func (k *keyringStore) Set(key, secret string) error {
+	_, err := runSecretCommand(secret, "secret-tool", "store", "--label", secretKeyPrefix+" "+key,
+		"service", secretKeyPrefix, "account", key)
+	return err
+}
+
+func (k *keyringStore) Delete(key string) error {
+	_, err := runSecretCommand("", "secret-tool", "clear", "service", secretKeyPrefix, "account", key)
+	return err
+}
+
+// passStore uses standard unix password manager (https://www.passwordstore.org/).
+type passStore struct{}
+
+func (p *passStore) Get(key string) (string, error) {
+	res, err := runSecretCommand("", "pass", "show", secretKeyPrefix+"/"+key)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(res, "\n")
+	return line, nil
+}
+
+func (p *passStore) Set(key, secret string) error {
+	_, err := runSecretCommand(secret+"\n", "pass", "insert", "--multiline", "--force", secretKeyPrefix+"/"+key)
+	return err
+}
+
+func (p *passStore) Delete(key string) error {
+	_, err := runSecretCommand("", "pass", "rm", "--force", secretKeyPrefix+"/"+key)
+	return err
+}
+
+// fileStore keeps secrets in AES-GCM encrypted file, key is derived from passphrase using PBKDF2.
+// File layout is salt (16 bytes) | nonce | ciphertext of JSON object.
+type fileStore struct {
+	path       string
+	passphrase func() (string, error)
+}
+
+func (f *fileStore) Get(key string) (string, error) {
+	secrets, _, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[key]
+	if !ok {
+		return "", fmt.Errorf("no secret stored for '%s'", key)
+	}
+	return secret, nil
+}
+
+func (f *fileStore) Set(key, secret string) error {
+	secrets, pass, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = secret
+	return f.save(secrets, pass)
+}
+
+func (f *fileStore) Delete(key string) error {
+	secrets, pass, err := f.load()
+	if err != nil {
+		return err
+	}
+	delete(secrets, key)
+	return f.save(secrets, pass)
+}
+
+func (f *fileStore) load() (map[string]string, string, error) {
+	secrets := make(map[string]string)
+	pass, err := f.passphrase()
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, pass, nil
+		}
+		return nil, "", err
+	}
+	if len(data) < 16 {
+		return nil, "", errors.New("secrets file is corrupted")
+	}
+	gcm, err := newSecretsCipher(pass, data[:16])
+	if err != nil {
+		return nil, "", err
+	}
+	data = data[16:]
+	if len(data) < gcm.NonceSize() {
+		return nil, "", errors.New("secrets file is corrupted")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, "", errors.New("unable to decrypt secrets file, wrong passphrase?")
+	}
+	return secrets, pass, json.Unmarshal(plain, &secrets)
+}
+
+func (f *fileStore) save(secrets map[string]string, pass string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := newSecretsCipher(pass, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+	data := append(salt, gcm.Seal(nonce, nonce, plain, nil)...)
+	return os.WriteFile(f.path, data, 0o600)
+}
+
+func newSecretsCipher(pass string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, pass, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	return newGcm(key)
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func readSecretsPassphrase() (string, error) {
+	if pass, ok := os.LookupEnv(secretsPassEnvName); ok {
+		return pass, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("passphrase for secrets file is required, set it using %s environment variable", secretsPassEnvName)
+	}
+	fmt.Fprint(os.Stderr, "Enter secrets passphrase: ")
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(pass), nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), secretsFileName)
+	fs := &fileStore{path: path, passphrase: func() (string, error) { return "passphrase", nil }}
+	assert.NoError(t, fs.Set("default", "secret1"))
+	assert.NoError(t, fs.Set("shared", "secret2"))
+	s, err := fs.Get("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret1", s)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret1")
+
+	assert.NoError(t, fs.Delete("default"))
+	_, err = fs.Get("default")
+	assert.Error(t, err)
+
+	fs.passphrase = func() (string, error) { return "wrong", nil }
+	_, err = fs.Get("shared")
+	assert.Error(t, err)
+}
+
+func TestLoadConfigFromFileStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("JD_CONFIG", filepath.Join(dir, "jdconfig.yaml"))
+	t.Setenv("JD_CONTEXT", "")
+	t.Setenv(secretsPassEnvName, "passphrase")
+
+	cfg, err := readConfig()
+	assert.NoError(t, err)
+	mail, pass := "me@example.com", "s3cr3t-p4ss"
+	cfg.SecretStore = secretStoreFile
+	cfg.Contexts[defaultContextName] = &contextData{Mail: &mail}
+	assert.NoError(t, cfg.setPassword(defaultContextName, &pass))
+	assert.NoError(t, saveConfig(cfg))
+
+	data, err := os.ReadFile(filepath.Join(dir, "jdconfig.yaml"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), pass)
+
+	ctx, err := loadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, pass, *ctx.Password)
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sessionCacheFileName = "jdsession.cache"
+	sessionCacheTTL      = time.Hour
+)
+
+// sessionCacheEntry holds session tokens of single context, encrypted using key derived from account password.
+type sessionCacheEntry struct {
+	Mail    string    `json:"mail"`
+	Expires time.Time `json:"expires"`
+	Data    []byte    `json:"data"`
+}
+
+type sessionInfo struct {
+	Context string    `json:"context"`
+	Mail    string    `json:"mail"`
+	Expires time.Time `json:"expires"`
+}
+
+var sessionCols = []column[sessionInfo]{
+	{name: "Context", value: func(s sessionInfo) string { return s.Context }},
+	{name: "Mail", value: func(s sessionInfo) string { return s.Mail }},
+	{name: "Expires", value: func(s sessionInfo) string { return s.Expires.Local().Format(time.RFC3339) }},
+	{name: "Remaining", value: func(s sessionInfo) string {
+		remaining := time.Until(s.Expires).Round(time.Second)
+		if remaining <= 0 {
+			return "expired"
+		}
+		return remaining.String()
+	}},
+}
+
+// connectClient returns client connected using cached session tokens when they are still accepted by server,
+// otherwise it performs full login and caches new session for subsequent invocations.
+func connectClient(cc *cliContext) (jdownloader.JdClient, *contextData, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = cc.applyConfig(cfg.Api); err != nil {
+		return nil, nil, err
+	}
+	name := cfg.resolveContextName(cc.context)
+	ctx, err := cfg.credentials(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger := cc.getLogger()
+	if sess := loadSession(name, ctx); sess != nil {
+		c := cc.newClient(ctx, jdownloader.ClientOptionSession(sess))
+		if _, err = c.ListDevices(); err == nil {
+			cc.cachedSession = name
+			return c, ctx, nil
+		}
+		logger.Debug("cached session was rejected, reconnecting", "error", err)
+	}
+	c := cc.newClient(ctx)
+	if err = c.Connect(); err != nil {
+		return nil, nil, err
+	}
+	if err = storeSession(name, ctx, c.Session()); err != nil {
+		logger.Warn("unable to cache session", "error", err)
+	} else {
+		cc.cachedSession = name
+	}
+	return c, ctx, nil
+}
+
+func getSessionCachePath() (string, error) {
+	cfgPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cfgPath), sessionCacheFileName), nil
+}
+
+func readSessionCache() (map[string]*sessionCacheEntry, error) {
+	cache := make(map[string]*sessionCacheEntry)
+	path, err := getSessionCachePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return nil, err
+	}
+	return cache, json.Unmarshal(data, &cache)
+}
+
+func writeSessionCache(cache map[string]*sessionCacheEntry) error {
+	path, err := getSessionCachePath()
+	if err != nil {
+		return err
+	}
+	if len(cache) == 0 {
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func newSessionKey(ctx *contextData, salt []byte) ([]byte, error) {
+	return hkdf.Key(sha256.New, []byte(*ctx.Password), salt, "jdcli session "+*ctx.Mail, 32)
+}
+
+// loadSession returns cached session of named context, or nil if there is no usable one.
+func loadSession(name string, ctx *contextData) *jdownloader.Session {
+	cache, err := readSessionCache()
+	if err != nil {
+		return nil
+	}
+	entry, ok := cache[name]
+	if !ok || entry.Mail != *ctx.Mail || time.Now().After(entry.Expires) || len(entry.Data) < 16 {
+		return nil
+	}
+	key, err := newSessionKey(ctx, entry.Data[:16])
+	if err != nil {
+		return nil
+	}
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil
+	}
+	data := entry.Data[16:]
+	if len(data) < gcm.NonceSize() {
+		return nil
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil
+	}
+	var sess jdownloader.Session
+	if err = json.Unmarshal(plain, &sess); err != nil {
+		return nil
+	}
+	return &sess
+}
+
+func storeSession(name string, ctx *contextData, sess *jdownloader.Session) error {
+	if sess == nil {
+		return errors.New("client has no session")
+	}
+	plain, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := newSessionKey(ctx, salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGcm(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+	cache, err := readSessionCache()
+	if err != nil {
+		return err
+	}
+	cache[name] = &sessionCacheEntry{
+		Mail:    *ctx.Mail,
+		Expires: time.Now().Add(sessionCacheTTL),
+		Data:    append(salt, gcm.Seal(nonce, nonce, plain, nil)...),
+	}
+	return writeSessionCache(cache)
+}
+
+// dropSession disconnects cached session of named context, if there is any, and removes it from cache.
+func dropSession(cc *cliContext, cfg *configData, name string) error {
+	if ctx, err := cfg.credentials(name); err == nil {
+		if sess := loadSession(name, ctx); sess != nil {
+			clientCloser(cc.newClient(ctx, jdownloader.ClientOptionSession(sess)), cc.out)
+		}
+	}
+	cache, err := readSessionCache()
+	if err != nil {
+		return err
+	}
+	delete(cache, name)
+	return writeSessionCache(cache)
+}
+
+func newSessionCommand(out io.Writer) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "session",
+		Short: "Manages cached sessions",
+	}
+	c.AddCommand(newSessionShowCommand(out))
+	c.AddCommand(newSessionClearCommand(out))
+	return c
+}
+
+func newSessionShowCommand(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show cached sessions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := readSessionCache()
+			if err != nil {
+				return err
+			}
+			sessions := make([]sessionInfo, 0, len(cache))
+			for name, entry := range cache {
+				sessions = append(sessions, sessionInfo{Context: name, Mail: entry.Mail, Expires: entry.Expires})
+			}
+			sort.Slice(sessions, func(i, j int) bool {
+				return sessions[i].Context < sessions[j].Context
+			})
+			return printList(cliContextOf(cmd).printer(), sessions, sessionCols)
+		},
+	}
+}
+
+func newSessionClearCommand(out io.Writer) *cobra.Command {
+	var all bool
+	c := &cobra.Command{
+		Use:   "clear",
+		Short: "Disconnect cached session of current context and remove it from cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return writeSessionCache(nil)
+			}
+			cfg, err := readConfig()
+			if err != nil {
+				return err
+			}
+			cc := cliContextOf(cmd)
+			name := cfg.resolveContextName(cc.context)
+			if err = dropSession(cc, cfg, name); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Session of context \"%s\" cleared\n", name)
+			return nil
+		},
+	}
+	c.Flags().BoolVar(&all, "all", all, "Remove cached sessions of all contexts without disconnecting them")
+	return c
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionCache(t *testing.T) {
+	t.Setenv("JD_CONFIG", filepath.Join(t.TempDir(), "jdconfig.yaml"))
+	mail, pass, other := "me@example.com", "secret", "changed"
+	ctx := &contextData{Mail: &mail, Password: &pass}
+	sess := &jdownloader.Session{SessionToken: "abc", RegainToken: "def"}
+
+	assert.Nil(t, loadSession("default", ctx))
+	assert.NoError(t, storeSession("default", ctx, sess))
+	assert.Equal(t, sess, loadSession("default", ctx))
+	assert.Nil(t, loadSession("other", ctx))
+	assert.Nil(t, loadSession("default", &contextData{Mail: &mail, Password: &other}))
+
+	cache, err := readSessionCache()
+	assert.NoError(t, err)
+	cache["default"].Expires = time.Now().Add(-time.Minute)
+	assert.NoError(t, writeSessionCache(cache))
+	assert.Nil(t, loadSession("default", ctx))
+
+	assert.NoError(t, dropSession(newCliContext(nil, nil, nil), &configData{}, "default"))
+	cache, err = readSessionCache()
+	assert.NoError(t, err)
+	assert.Empty(t, cache)
+}
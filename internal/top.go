@@ -0,0 +1,435 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	topViewPackages = iota
+	topViewLinks
+)
+
+const (
+	ansiClear   = "\x1b[H\x1b[2J"
+	ansiReverse = "\x1b[7m"
+	ansiBold    = "\x1b[1m"
+	ansiReset   = "\x1b[0m"
+	ansiHide    = "\x1b[?25l"
+	ansiShow    = "\x1b[?25h"
+	topHelp     = "q:quit tab:view j/k:select o:order R:reverse r:refresh e:enable/disable d:remove P/S:pause/start all"
+)
+
+type topSort[T any] struct {
+	name string
+	cmp  func(a, b T) int
+}
+
+var (
+	topPkgSorts = []topSort[jdownloader.FilePackage]{
+		{"name", func(a, b jdownloader.FilePackage) int { return cmp.Compare(strVal(a.Name), strVal(b.Name)) }},
+		{"progress", func(a, b jdownloader.FilePackage) int {
+			return cmp.Compare(progress(a.BytesLoaded, a.BytesTotal), progress(b.BytesLoaded, b.BytesTotal))
+		}},
+		{"speed", func(a, b jdownloader.FilePackage) int { return cmp.Compare(floatVal(a.Speed), floatVal(b.Speed)) }},
+		{"eta", func(a, b jdownloader.FilePackage) int { return cmp.Compare(intVal(a.Eta), intVal(b.Eta)) }},
+		{"size", func(a, b jdownloader.FilePackage) int { return cmp.Compare(intVal(a.BytesTotal), intVal(b.BytesTotal)) }},
+	}
+	topLinkSorts = []topSort[jdownloader.DownloadLink]{
+		{"name", func(a, b jdownloader.DownloadLink) int { return cmp.Compare(strVal(a.Name), strVal(b.Name)) }},
+		{"progress", func(a, b jdownloader.DownloadLink) int {
+			return cmp.Compare(progress(a.BytesLoaded, a.BytesTotal), progress(b.BytesLoaded, b.BytesTotal))
+		}},
+		{"speed", func(a, b jdownloader.DownloadLink) int { return cmp.Compare(floatVal(a.Speed), floatVal(b.Speed)) }},
+		{"eta", func(a, b jdownloader.DownloadLink) int { return cmp.Compare(intVal(a.Eta), intVal(b.Eta)) }},
+		{"size", func(a, b jdownloader.DownloadLink) int {
+			return cmp.Compare(intVal(a.BytesTotal), intVal(b.BytesTotal))
+		}},
+		{"host", func(a, b jdownloader.DownloadLink) int { return cmp.Compare(strVal(a.Host), strVal(b.Host)) }},
+	}
+)
+
+type topModel struct {
+	dl       jdownloader.Downloader
+	state    string
+	speed    *float64
+	pkgs     []jdownloader.FilePackage
+	links    []jdownloader.DownloadLink
+	view     int
+	sortBy   int
+	desc     bool
+	selected int
+	confirm  bool
+	message  string
+	// stale is set when data should be fetched again before next render
+	stale bool
+}
+
+// topSelection is link or package under cursor, with ids suitable for downloader API calls.
+type topSelection struct {
+	name    string
+	linkIds []int64
+	pkgIds  []int64
+	enabled bool
+}
+
+func newDownloadTopCommand(out io.Writer) *cobra.Command {
+	type topData struct {
+		interval time.Duration
+		count    int
+	}
+	var data topData
+	data.interval = 2 * time.Second
+	c := &cobra.Command{
+		Use:   "top",
+		Short: "Display refreshing view of downloads",
+		Long: `Display full-screen, periodically refreshed view of download packages and links.
+When standard output is not a terminal, plain status is printed on every refresh instead.
+
+Keys: ` + topHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				m := &topModel{dl: dev.Downloader(), stale: true}
+				if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) && term.IsTerminal(int(os.Stdin.Fd())) {
+					return m.runInteractive(cmd.Context(), f, data.interval)
+				}
+				return m.runPlain(cmd.Context(), out, data.interval, data.count)
+			})
+		},
+	}
+	c.Flags().DurationVar(&data.interval, "interval", data.interval, "Refresh interval")
+	c.Flags().IntVar(&data.count, "count", data.count, "Number of refreshes in non-interactive mode, 0 means no limit")
+	return c
+}
+
+func (m *topModel) refresh() error {
+	st, err := m.dl.State()
+	if err != nil {
+		return err
+	}
+	si, err := m.dl.Speed()
+	if err != nil {
+		return err
+	}
+	pkgs, err := m.dl.Packages()
+	if err != nil {
+		return err
+	}
+	links, err := m.dl.Links()
+	if err != nil {
+		return err
+	}
+	m.keepSelection(func() {
+		m.state, m.speed, m.pkgs, m.links = strVal(st.State), si.Speed, *pkgs, *links
+		m.sort()
+	})
+	return nil
+}
+
+// rowUuid returns UUID of package or link shown at given row of current view.
+func (m *topModel) rowUuid(i int) *int64 {
+	if m.view == topViewPackages {
+		return m.pkgs[i].Uuid
+	}
+	return m.links[i].Uuid
+}
+
+// keepSelection applies change which may reorder rows, selection then follows the same package or link.
+func (m *topModel) keepSelection(change func()) {
+	var selected *int64
+	if m.selected < m.rows() {
+		selected = m.rowUuid(m.selected)
+	}
+	change()
+	if selected == nil {
+		return
+	}
+	for i := range m.rows() {
+		if id := m.rowUuid(i); id != nil && *id == *selected {
+			m.selected = i
+			return
+		}
+	}
+}
+
+func (m *topModel) sort() {
+	sign := 1
+	if m.desc {
+		sign = -1
+	}
+	if m.view == topViewPackages {
+		s := topPkgSorts[m.sortBy%len(topPkgSorts)]
+		slices.SortStableFunc(m.pkgs, func(a, b jdownloader.FilePackage) int { return sign * s.cmp(a, b) })
+	} else {
+		s := topLinkSorts[m.sortBy%len(topLinkSorts)]
+		slices.SortStableFunc(m.links, func(a, b jdownloader.DownloadLink) int { return sign * s.cmp(a, b) })
+	}
+}
+
+func (m *topModel) rows() int {
+	if m.view == topViewPackages {
+		return len(m.pkgs)
+	}
+	return len(m.links)
+}
+
+func (m *topModel) sortName() string {
+	if m.view == topViewPackages {
+		return topPkgSorts[m.sortBy%len(topPkgSorts)].name
+	}
+	return topLinkSorts[m.sortBy%len(topLinkSorts)].name
+}
+
+// selection returns selected link or package, nil is returned when there is nothing to act on.
+func (m *topModel) selection() *topSelection {
+	if m.selected >= m.rows() {
+		return nil
+	}
+	if m.view == topViewPackages {
+		pkg := m.pkgs[m.selected]
+		if pkg.Uuid == nil {
+			return nil
+		}
+		return &topSelection{name: strVal(pkg.Name), linkIds: []int64{}, pkgIds: []int64{*pkg.Uuid},
+			enabled: pkg.Enabled == nil || *pkg.Enabled}
+	}
+	link := m.links[m.selected]
+	if link.Uuid == nil {
+		return nil
+	}
+	return &topSelection{name: strVal(link.Name), linkIds: []int64{*link.Uuid}, pkgIds: []int64{},
+		enabled: link.Enabled == nil || *link.Enabled}
+}
+
+// handleKey performs action bound to key and returns false when view should be closed.
+func (m *topModel) handleKey(key string) bool {
+	if m.confirm {
+		m.confirm = false
+		if key != "y" {
+			m.message = "Removal cancelled"
+			return true
+		}
+		if sel := m.selection(); sel != nil {
+			m.message = m.result(fmt.Sprintf("Removed %s", sel.name), m.dl.Remove(sel.linkIds, sel.pkgIds))
+			m.stale = true
+		}
+		return true
+	}
+	switch key {
+	case "q", "\x03":
+		return false
+	case "\t":
+		m.view = (m.view + 1) % 2
+		m.selected, m.sortBy = 0, 0
+	case "j", "\x1b[B":
+		m.selected = min(m.selected+1, max(m.rows()-1, 0))
+	case "k", "\x1b[A":
+		m.selected = max(m.selected-1, 0)
+	case "o":
+		m.sortBy++
+	case "R":
+		m.desc = !m.desc
+	case "r":
+		m.stale = true
+	case "e":
+		if sel := m.selection(); sel != nil {
+			action := "Enabled"
+			if sel.enabled {
+				action = "Disabled"
+			}
+			m.message = m.result(fmt.Sprintf("%s %s", action, sel.name), m.dl.SetEnabled(!sel.enabled, sel.linkIds, sel.pkgIds))
+			m.stale = true
+		}
+	case "d":
+		if sel := m.selection(); sel != nil {
+			m.confirm = true
+			m.message = fmt.Sprintf("Remove %s? [y/N]", sel.name)
+		}
+	case "P":
+		_, err := m.dl.Pause()
+		m.message = m.result("Downloads paused", err)
+		m.stale = true
+	case "S":
+		_, err := m.dl.Start()
+		m.message = m.result("Downloads started", err)
+		m.stale = true
+	}
+	m.keepSelection(m.sort)
+	return true
+}
+
+func (m *topModel) result(msg string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return msg
+}
+
+func (m *topModel) render(width, height int) string {
+	var sb strings.Builder
+	sb.WriteString(ansiClear)
+	fmt.Fprintf(&sb, "%sjdcli top%s - %s - state: %s, speed: %s, packages: %d, links: %d\r\n",
+		ansiBold, ansiReset, time.Now().Format(time.TimeOnly), m.state, formatSpeed(m.speed), len(m.pkgs), len(m.links))
+	order := "asc"
+	if m.desc {
+		order = "desc"
+	}
+	fmt.Fprintf(&sb, "%s\r\n", fit(fmt.Sprintf("sort: %s %s | %s", m.sortName(), order, topHelp), width))
+	fmt.Fprintf(&sb, "%s\r\n", fit(m.message, width))
+
+	nameWidth := max(width-70, 10)
+	var header string
+	lines := make([]string, 0, m.rows())
+	if m.view == topViewPackages {
+		header = fmt.Sprintf("%-*s %-28s %-21s %-12s %s", nameWidth, "PACKAGE", "PROGRESS", "LOADED", "SPEED", "ETA")
+		for _, pkg := range m.pkgs {
+			lines = append(lines, fmt.Sprintf("%-*s %-28s %-21s %-12s %s", nameWidth, fit(strVal(pkg.Name), nameWidth),
+				progressBar(pkg.BytesLoaded, pkg.BytesTotal, 20),
+				formatSize(pkg.BytesLoaded)+"/"+formatSize(pkg.BytesTotal), formatSpeed(pkg.Speed), formatEta(pkg.Eta)))
+		}
+	} else {
+		header = fmt.Sprintf("%-*s %-16s %-7s %-12s %-16s %s", nameWidth, "LINK", "HOST", "DONE", "SPEED", "ETA", "STATUS")
+		for _, link := range m.links {
+			lines = append(lines, fmt.Sprintf("%-*s %-16s %6.1f%% %-12s %-16s %s", nameWidth, fit(strVal(link.Name), nameWidth),
+				fit(strVal(link.Host), 16), progress(link.BytesLoaded, link.BytesTotal)*100,
+				formatSpeed(link.Speed), formatEta(link.Eta), strVal(link.Status)))
+		}
+	}
+	fmt.Fprintf(&sb, "%s%s%s\r\n", ansiReverse, fit(header, width), ansiReset)
+
+	visible := max(height-5, 1)
+	m.selected = min(m.selected, max(len(lines)-1, 0))
+	first := max(m.selected-visible+1, 0)
+	for i := first; i < len(lines) && i < first+visible; i++ {
+		if i == m.selected {
+			fmt.Fprintf(&sb, "%s%s%s\r\n", ansiReverse, fit(lines[i], width), ansiReset)
+		} else {
+			fmt.Fprintf(&sb, "%s\r\n", fit(lines[i], width))
+		}
+	}
+	return sb.String()
+}
+
+func (m *topModel) runInteractive(ctx context.Context, f *os.File, interval time.Duration) error {
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = term.Restore(int(os.Stdin.Fd()), oldState)
+		fmt.Fprint(f, ansiShow+ansiClear)
+	}()
+	fmt.Fprint(f, ansiHide)
+
+	keys := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			select {
+			case keys <- string(buf[:n]):
+			case <-done:
+				return
+			}
+		}
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if m.stale {
+			if err = m.refresh(); err != nil {
+				m.message = fmt.Sprintf("Error: %v", err)
+			}
+			m.stale = false
+		}
+		width, height, err := term.GetSize(int(f.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Fprint(f, m.render(width, height))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.stale = true
+		case key, ok := <-keys:
+			if !ok || !m.handleKey(key) {
+				return nil
+			}
+		}
+	}
+}
+
+func (m *topModel) runPlain(ctx context.Context, out io.Writer, interval time.Duration, count int) error {
+	for i := 0; count == 0 || i < count; i++ {
+		if i > 0 {
+			if err := sleepCtx(ctx, interval); err != nil {
+				return err
+			}
+			fmt.Fprintln(out)
+		}
+		if err := m.refresh(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "[%s] Download status: %s, speed: %s\n", time.Now().Format(time.TimeOnly), m.state, formatSpeed(m.speed))
+		if err := printTable(out, m.pkgs, pkgCols, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func progress(loaded, total *int64) float64 {
+	if loaded == nil || total == nil || *total <= 0 {
+		return 0
+	}
+	return max(min(float64(*loaded)/float64(*total), 1), 0)
+}
+
+func progressBar(loaded, total *int64, width int) string {
+	p := progress(loaded, total)
+	width = max(width, 0)
+	done := int(p * float64(width))
+	return fmt.Sprintf("[%s%s] %5.1f%%", strings.Repeat("#", done), strings.Repeat(".", width-done), p*100)
+}
+
+// fit truncates string to given width, so that lines don't wrap in terminal.
+func fit(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressBar(t *testing.T) {
+	assert.Equal(t, "[#####.....]  50.0%", progressBar(pint64(50), pint64(100), 10))
+	assert.Equal(t, "[..........]   0.0%", progressBar(nil, pint64(100), 10))
+	assert.Equal(t, "[..........]   0.0%", progressBar(pint64(-50), pint64(100), 10))
+	assert.Equal(t, "[##########] 100.0%", progressBar(pint64(150), pint64(100), 10))
+	assert.Equal(t, "[..........]   0.0%", progressBar(pint64(50), pint64(0), 10))
+	assert.Equal(t, "abc", fit("abcdef", 3))
+}
+
+func TestTopModelKeys(t *testing.T) {
+	m := &topModel{pkgs: []jdownloader.FilePackage{
+		{Uuid: pint64(1), Name: pstr("b"), BytesTotal: pint64(10)},
+		{Uuid: pint64(2), Name: pstr("a"), BytesTotal: pint64(20)},
+	}}
+	m.sort()
+	assert.Equal(t, "a", *m.pkgs[0].Name)
+	assert.True(t, m.handleKey("R"))
+	assert.Equal(t, "b", *m.pkgs[0].Name)
+	assert.False(t, m.stale)
+	assert.True(t, m.handleKey("r"))
+	assert.True(t, m.stale)
+	assert.True(t, m.handleKey("j"))
+	assert.True(t, m.handleKey("j"))
+	assert.Equal(t, 1, m.selected)
+	assert.True(t, m.handleKey("d"))
+	assert.True(t, m.confirm)
+	assert.True(t, m.handleKey("n"))
+	assert.Equal(t, "Removal cancelled", m.message)
+	assert.Contains(t, m.render(120, 10), "PACKAGE")
+	assert.False(t, m.handleKey("q"))
+
+	m.pkgs = []jdownloader.FilePackage{{Name: pstr("no uuid")}}
+	m.selected = 0
+	assert.Nil(t, m.selection())
+	m.message = ""
+	assert.True(t, m.handleKey("e"))
+	assert.True(t, m.handleKey("d"))
+	assert.False(t, m.confirm)
+	assert.Empty(t, m.message)
+}
+
+type enableRecorder struct {
+	jdownloader.Downloader
+	enabled *bool
+	pkgIds  []int64
+}
+
+func (r *enableRecorder) SetEnabled(enabled bool, _ []int64, pkgIds []int64) error {
+	r.enabled, r.pkgIds = &enabled, pkgIds
+	return nil
+}
+
+func TestTopModelToggleEnabled(t *testing.T) {
+	rec := &enableRecorder{}
+	m := &topModel{dl: rec, pkgs: []jdownloader.FilePackage{{Uuid: pint64(1), Name: pstr("a")}}}
+	assert.True(t, m.handleKey("e"))
+	assert.Equal(t, pbool(false), rec.enabled)
+	assert.Equal(t, []int64{1}, rec.pkgIds)
+	assert.Equal(t, "Disabled a", m.message)
+	assert.True(t, m.stale)
+
+	m.pkgs[0].Enabled = pbool(false)
+	assert.True(t, m.handleKey("e"))
+	assert.Equal(t, pbool(true), rec.enabled)
+	assert.Equal(t, "Enabled a", m.message)
+}
+
+func TestTopModelKeepsSelection(t *testing.T) {
+	m := &topModel{pkgs: []jdownloader.FilePackage{
+		{Uuid: pint64(1), Name: pstr("a")},
+		{Uuid: pint64(2), Name: pstr("b")},
+	}}
+	assert.True(t, m.handleKey("j"))
+	assert.Equal(t, 1, m.selected)
+	assert.True(t, m.handleKey("R"))
+	assert.Equal(t, 0, m.selected)
+	assert.Equal(t, "b", *m.pkgs[m.selected].Name)
+
+	m.keepSelection(func() {
+		m.pkgs = []jdownloader.FilePackage{{Uuid: pint64(3), Name: pstr("c")}, {Uuid: pint64(2), Name: pstr("b")}}
+	})
+	assert.Equal(t, 1, m.selected)
+	m.keepSelection(func() {
+		m.pkgs = []jdownloader.FilePackage{{Uuid: pint64(3), Name: pstr("c")}}
+	})
+	assert.Equal(t, 1, m.selected)
+	assert.Contains(t, m.render(120, 10), "c")
+	assert.Equal(t, 0, m.selected)
+}
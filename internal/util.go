@@ -20,54 +20,56 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rkosegi/jdownloader-go/jdownloader"
-	xlog "github.com/rkosegi/slog-config"
-	"github.com/spf13/pflag"
+	"github.com/spf13/cobra"
 )
 
-func addDeviceFlag(fs *pflag.FlagSet, target *string) {
-	fs.StringVar(target, "device", *target, "Device name to use for this operation")
-}
-
-func addDebugFlag(fs *pflag.FlagSet, target *bool) {
-	fs.BoolVar(target, "debug", *target, "Debugging flag")
-}
-
-func addJsonFlag(fs *pflag.FlagSet, target *bool) {
-	fs.BoolVar(target, "json", *target, "JSON output flag")
-}
-
 func pickDevice(client jdownloader.JdClient) (string, error) {
 	devs, err := client.ListDevices()
 	if err != nil {
 		return "", err
 	}
 	if len(*devs) == 0 {
-		return "", errors.New("no device available")
+		return "", withExitCode(exitCodeNoDevice, errors.New("no device available"))
 	}
 	a := *devs
+	if len(a) > 1 {
+		return "", withExitCode(exitCodeUsage, fmt.Errorf("multiple devices available (%s), choose one using --device flag, "+
+			"JD_DEVICE environment variable or 'jdcli device use'", deviceNames(a)))
+	}
 	return a[0].Name, err
 }
 
-func doWithDevice(debug bool, devname string, out io.Writer, fn func(device jdownloader.Device) error) error {
-	c, err := getClient(debug)
-	if err != nil {
-		return err
+func deviceNames(devs []jdownloader.DeviceInfo) string {
+	names := make([]string, len(devs))
+	for i, dev := range devs {
+		names[i] = dev.Name
 	}
-	err = c.Connect()
-	if err != nil {
-		return err
+	return strings.Join(names, ", ")
+}
+
+// resolveDeviceName picks device by precedence: --device flag, JD_DEVICE environment variable,
+// default device of config context, single available device.
+func resolveDeviceName(client jdownloader.JdClient, devname string, cfg *contextData) (string, error) {
+	if len(devname) > 0 {
+		return devname, nil
+	}
+	if env := os.Getenv("JD_DEVICE"); len(env) > 0 {
+		return env, nil
 	}
-	defer clientCloser(c, out)
-	if len(devname) == 0 {
-		devname, err = pickDevice(c)
-		if err != nil {
-			return err
-		}
+	if cfg != nil && cfg.Device != nil && len(*cfg.Device) > 0 {
+		return *cfg.Device, nil
 	}
-	dev, err := c.Device(devname)
+	return pickDevice(client)
+}
+
+func doWithDevice(cmd *cobra.Command, fn func(device jdownloader.Device) error) error {
+	dev, err := cliContextOf(cmd).getDevice()
 	if err != nil {
 		return err
 	}
@@ -121,17 +123,45 @@ func formatSpeed(speed *float64) string {
 	return fmt.Sprintf("%s/s", formatSize(&size))
 }
 
-func compressUrl(url string) string {
-	if len(url) > 80 {
-		return url[0:80]
+// formatDate formats timestamp given in milliseconds, JDownloader uses negative values for unset dates.
+func formatDate(millis *int64) string {
+	if millis == nil || *millis <= 0 {
+		return ""
+	}
+	return time.UnixMilli(*millis).Local().Format(time.DateTime)
+}
+
+func strVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func boolVal(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+func idVal(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
+
+func floatVal(f *float64) float64 {
+	if f == nil {
+		return 0
 	}
-	return url
+	return *f
 }
 
-func getLogger(debug bool) *slog.Logger {
-	level := "info"
-	if debug {
-		level = "debug"
+func intVal(i *int64) int64 {
+	if i == nil {
+		return 0
 	}
-	return xlog.MustNew(level, xlog.LogFormatLogFmt).Logger()
+	return *i
 }
@@ -33,6 +33,31 @@ func TestPickDevice(t *testing.T) {
 	dev, err := pickDevice(mc)
 	assert.NoError(t, err)
 	assert.Equal(t, "mock", dev)
+	mc.SetDevices(&[]jdownloader.DeviceInfo{{Name: "nas"}, {Name: "desktop"}})
+	_, err = pickDevice(mc)
+	assert.ErrorContains(t, err, "nas, desktop")
+}
+
+func TestResolveDeviceName(t *testing.T) {
+	mc := jdownloader.NewMockClient()
+	mc.SetDevices(&[]jdownloader.DeviceInfo{{Name: "nas"}, {Name: "desktop"}})
+	def := "desktop"
+	cfg := &contextData{Device: &def}
+	t.Setenv("JD_DEVICE", "")
+
+	dev, err := resolveDeviceName(mc, "nas", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "nas", dev)
+	dev, err = resolveDeviceName(mc, "", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "desktop", dev)
+	_, err = resolveDeviceName(mc, "", &contextData{})
+	assert.Error(t, err)
+
+	t.Setenv("JD_DEVICE", "other")
+	dev, err = resolveDeviceName(mc, "", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", dev)
 }
 
 func pfloat64(c float64) *float64 {
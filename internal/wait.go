@@ -0,0 +1,160 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+type waitProgress struct {
+	total       int
+	finished    int
+	failed      int
+	bytesLoaded int64
+	bytesTotal  int64
+	speed       float64
+}
+
+func (p *waitProgress) done() bool {
+	return p.finished+p.failed == p.total
+}
+
+func (p *waitProgress) String() string {
+	return fmt.Sprintf("%d/%d links finished, %d failed, %s / %s, %s",
+		p.finished, p.total, p.failed, formatSize(&p.bytesLoaded), formatSize(&p.bytesTotal), formatSpeed(&p.speed))
+}
+
+func newDownloadWaitCommand(out io.Writer) *cobra.Command {
+	type waitData struct {
+		packages []string
+		links    []int64
+		timeout  time.Duration
+		poll     time.Duration
+	}
+	var data waitData
+	data.poll = 10 * time.Second
+	c := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait until links of selected packages (or all links) are finished",
+		Long: fmt.Sprintf(`Wait until all matching links are finished or failed.
+Exit code is 0 when all links finished, %d when some of them failed or are offline and %d on timeout.
+Maximum time to wait is given by --wait-timeout, global --timeout limits single API call only.`,
+			exitCodePartialFailure, exitCodeTimeout),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if data.poll <= 0 {
+				return errors.New("poll interval must be positive")
+			}
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				pkgIds := make(map[int64]bool)
+				if len(data.packages) > 0 {
+					pkgs, err := dl.Packages()
+					if err != nil {
+						return err
+					}
+					if pkgIds, err = matchPackages(*pkgs, data.packages); err != nil {
+						return err
+					}
+				}
+				var deadline time.Time
+				if data.timeout > 0 {
+					deadline = time.Now().Add(data.timeout)
+				}
+				for {
+					links, err := dl.Links()
+					if err != nil {
+						return err
+					}
+					p := waitForLinks(*links, pkgIds, data.links)
+					if p.total == 0 {
+						return withExitCode(exitCodeNotFound, errors.New("no matching links found"))
+					}
+					fmt.Fprintf(out, "[%s] %s\n", time.Now().Format(time.TimeOnly), p)
+					if p.done() {
+						if p.failed > 0 {
+							return withExitCode(exitCodePartialFailure, fmt.Errorf("%d of %d links failed", p.failed, p.total))
+						}
+						return nil
+					}
+					if deadlinePassed(deadline) {
+						return withExitCode(exitCodeTimeout, fmt.Errorf("timeout after %s: %s", data.timeout, p))
+					}
+					if err = sleepCtx(cmd.Context(), pollDelay(data.poll, deadline)); err != nil {
+						return err
+					}
+				}
+			})
+		},
+	}
+	c.Flags().StringArrayVar(&data.packages, "package", data.packages, "Package UUID or name to wait for. Can be specified multiple times")
+	c.Flags().Int64SliceVar(&data.links, "link", data.links, "Link UUID to wait for. Can be specified multiple times")
+	c.Flags().DurationVar(&data.timeout, "wait-timeout", data.timeout, "Maximum time to wait, 0 means no limit")
+	c.Flags().DurationVar(&data.poll, "poll", data.poll, "Interval between status checks")
+	return c
+}
+
+// deadlinePassed tells whether deadline is set and already passed.
+func deadlinePassed(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// pollDelay returns how long to sleep before next status check, so that last check happens right at deadline.
+func pollDelay(poll time.Duration, deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return poll
+	}
+	return max(min(poll, time.Until(deadline)), 0)
+}
+
+// waitForLinks computes progress of links matching package or link selectors.
+// When no selector is given, all links are matched.
+func waitForLinks(links []jdownloader.DownloadLink, pkgIds map[int64]bool, linkIds []int64) *waitProgress {
+	p := &waitProgress{}
+	for _, link := range links {
+		if len(pkgIds) > 0 || len(linkIds) > 0 {
+			inPkg := link.PackageUuid != nil && pkgIds[*link.PackageUuid]
+			isLink := link.Uuid != nil && slices.Contains(linkIds, *link.Uuid)
+			if !inPkg && !isLink {
+				continue
+			}
+		}
+		p.total++
+		switch {
+		case isLinkFinished(link):
+			p.finished++
+		case isLinkFailed(link):
+			p.failed++
+		}
+		if link.BytesLoaded != nil {
+			p.bytesLoaded += *link.BytesLoaded
+		}
+		if link.BytesTotal != nil {
+			p.bytesTotal += *link.BytesTotal
+		}
+		if link.Speed != nil {
+			p.speed += *link.Speed
+		}
+	}
+	return p
+}
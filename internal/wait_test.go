@@ -0,0 +1,99 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func pbool(b bool) *bool {
+	return &b
+}
+
+func pstr(s string) *string {
+	return &s
+}
+
+func TestWaitForLinks(t *testing.T) {
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Finished: pbool(true), BytesLoaded: pint64(100), BytesTotal: pint64(100)},
+		{Uuid: pint64(2), PackageUuid: pint64(10), StatusIconKey: pstr("false"), BytesTotal: pint64(100)},
+		{Uuid: pint64(3), PackageUuid: pint64(20), BytesLoaded: pint64(50), BytesTotal: pint64(100)},
+	}
+	p := waitForLinks(links, map[int64]bool{}, nil)
+	assert.Equal(t, 3, p.total)
+	assert.Equal(t, int64(150), p.bytesLoaded)
+	assert.False(t, p.done())
+
+	p = waitForLinks(links, map[int64]bool{10: true}, nil)
+	assert.Equal(t, 2, p.total)
+	assert.Equal(t, 1, p.failed)
+	assert.True(t, p.done())
+
+	p = waitForLinks(links, map[int64]bool{}, []int64{1})
+	assert.Equal(t, 1, p.total)
+	assert.Equal(t, 1, p.finished)
+}
+
+func TestMatchPackages(t *testing.T) {
+	pkgs := []jdownloader.FilePackage{{Uuid: pint64(10), Name: pstr("movies")}, {Uuid: pint64(20), Name: pstr("music")}}
+	ids, err := matchPackages(pkgs, []string{"movies", "20"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{10: true, 20: true}, ids)
+	_, err = matchPackages(pkgs, []string{"books"})
+	assert.Error(t, err)
+}
+
+func TestExitCode(t *testing.T) {
+	_, ok := ExitCode(errors.New("plain"))
+	assert.False(t, ok)
+	code, ok := ExitCode(withExitCode(exitCodeTimeout, errors.New("timeout")))
+	assert.True(t, ok)
+	assert.Equal(t, exitCodeTimeout, code)
+}
+
+func TestPollDelay(t *testing.T) {
+	assert.Equal(t, 10*time.Second, pollDelay(10*time.Second, time.Time{}))
+	assert.Equal(t, 10*time.Second, pollDelay(10*time.Second, time.Now().Add(time.Hour)))
+	d := pollDelay(10*time.Second, time.Now().Add(3*time.Second))
+	assert.Greater(t, d, 2*time.Second)
+	assert.LessOrEqual(t, d, 3*time.Second)
+	assert.Equal(t, time.Duration(0), pollDelay(10*time.Second, time.Now().Add(-time.Second)))
+
+	assert.False(t, deadlinePassed(time.Time{}))
+	assert.False(t, deadlinePassed(time.Now().Add(time.Hour)))
+	assert.True(t, deadlinePassed(time.Now().Add(-time.Second)))
+}
+
+func TestLinkOutcome(t *testing.T) {
+	offline := jdownloader.DownloadLink{StatusIconKey: pstr("false")}
+	assert.Equal(t, linkOutcomeOffline, linkOutcome(offline))
+	assert.Equal(t, notifyEventOffline, linkEvent(offline))
+	assert.True(t, isLinkFailed(offline))
+	skipped := jdownloader.DownloadLink{Skipped: pbool(true)}
+	assert.Equal(t, linkOutcomeFailed, linkOutcome(skipped))
+	assert.True(t, isLinkFailed(skipped))
+	finished := jdownloader.DownloadLink{Finished: pbool(true), StatusIconKey: pstr("false")}
+	assert.Equal(t, linkOutcomeFinished, linkOutcome(finished))
+	assert.False(t, isLinkFailed(finished))
+	assert.Equal(t, "", linkOutcome(jdownloader.DownloadLink{}))
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/spf13/cobra"
+)
+
+const (
+	watchStateFileName = "jdwatch.state"
+	packageFinished    = "finished"
+	packageFailed      = "failed"
+)
+
+// shellQuoted is value which was already quoted for shell, so it is not quoted again.
+type shellQuoted string
+
+// hookData is passed to hook templates, so that fields of package are available as {{.Name}}, {{.SaveTo}}, ...
+type hookData struct {
+	jdownloader.FilePackage
+	Event string
+}
+
+type watchStateEntry struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+}
+
+var hookFuncs = template.FuncMap{
+	"quote": shellQuote,
+}
+
+func newDownloadWatchCommand(out io.Writer) *cobra.Command {
+	type watchData struct {
+		onFinish  string
+		onFailure string
+		stateFile string
+		poll      time.Duration
+		once      bool
+		clean     bool
+	}
+	var data watchData
+	data.poll = 30 * time.Second
+	c := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch download packages and run hooks when they finish or fail",
+		Long: `Watch download packages and run hooks when they finish or fail.
+
+Hooks are Go templates rendered with package fields ({{.Name}}, {{.SaveTo}}, {{.Uuid}}, ...) and {{.Event}}
+and run by sh (hooks are not supported on Windows). Every interpolated value is quoted for shell, so it is passed
+as single word and must not be quoted in template again. Package is also described by JD_PACKAGE_UUID,
+JD_PACKAGE_NAME, JD_PACKAGE_SAVE_TO and JD_PACKAGE_EVENT environment variables, which is the safe way to use values
+inside more complex scripts. Each package triggers hooks only once, packages which already triggered are recorded
+in state file until they are removed from download list. When state file does not exist yet, packages which already finished or failed are recorded without running hooks.`,
+		Example: `  jdcli download watch --on-finish 'notify-send Downloaded {{.Name}}'
+  jdcli download watch --on-finish 'mv "$JD_PACKAGE_SAVE_TO" /media/done' --once`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(data.onFinish) == 0 && len(data.onFailure) == 0 {
+				return errors.New("no hook specified (use --on-finish and/or --on-failure)")
+			}
+			hooks := make(map[string]*template.Template)
+			for event, hook := range map[string]string{packageFinished: data.onFinish, packageFailed: data.onFailure} {
+				if len(hook) == 0 {
+					continue
+				}
+				tpl, err := parseHook(event, hook)
+				if err != nil {
+					return err
+				}
+				hooks[event] = tpl
+			}
+			if len(data.stateFile) == 0 {
+				cfgPath, err := getConfigPath()
+				if err != nil {
+					return err
+				}
+				data.stateFile = filepath.Join(filepath.Dir(cfgPath), watchStateFileName)
+			}
+			_, err := os.Stat(data.stateFile)
+			seed := os.IsNotExist(err)
+			return doWithDevice(cmd, func(dev jdownloader.Device) error {
+				dl := dev.Downloader()
+				for {
+					state, err := readWatchState(data.stateFile)
+					if err != nil {
+						return err
+					}
+					pkgs, err := dl.Packages()
+					if err != nil {
+						return err
+					}
+					links, err := dl.Links()
+					if err != nil {
+						return err
+					}
+					for _, pkg := range *pkgs {
+						event := packageEvent(pkg, *links)
+						if len(event) == 0 || pkg.Uuid == nil {
+							continue
+						}
+						if _, done := state[idVal(pkg.Uuid)]; done {
+							continue
+						}
+						state[idVal(pkg.Uuid)] = watchStateEntry{Event: event, Time: time.Now()}
+						if seed {
+							continue
+						}
+						fmt.Fprintf(out, "Package %s %s\n", strVal(pkg.Name), event)
+						if tpl, ok := hooks[event]; ok {
+							if err = runHook(out, tpl, hookData{FilePackage: pkg, Event: event}); err != nil {
+								fmt.Fprintf(out, "Hook for package %s failed: %v\n", strVal(pkg.Name), err)
+							}
+						}
+						if data.clean && event == packageFinished {
+							if err = cleanPackage(out, dl, pkg, *links); err != nil {
+								return err
+							}
+						}
+						if err = writeWatchState(data.stateFile, state); err != nil {
+							return err
+						}
+					}
+					if pruneWatchState(state, *pkgs) || seed {
+						seed = false
+						if err = writeWatchState(data.stateFile, state); err != nil {
+							return err
+						}
+					}
+					if data.once {
+						return nil
+					}
+					if err = sleepCtx(cmd.Context(), data.poll); err != nil {
+						return err
+					}
+				}
+			})
+		},
+	}
+	c.Flags().StringVar(&data.onFinish, "on-finish", data.onFinish, "Command template to run when package finishes")
+	c.Flags().StringVar(&data.onFailure, "on-failure", data.onFailure, "Command template to run when package fails")
+	c.Flags().StringVar(&data.stateFile, "state-file", data.stateFile, "Path to file which records packages that already triggered hooks (defaults to file next to config)")
+	c.Flags().DurationVar(&data.poll, "poll", data.poll, "Interval between status checks")
+	c.Flags().BoolVar(&data.once, "once", data.once, "Check packages only once and exit, useful when run from cron")
+	c.Flags().BoolVar(&data.clean, "clean", data.clean, "Remove finished links of package from download list after hook was run")
+	return c
+}
+
+// packageEvent returns packageFinished or packageFailed once all links of package settled, empty string otherwise.
+func packageEvent(pkg jdownloader.FilePackage, links []jdownloader.DownloadLink) string {
+	if pkg.Uuid == nil {
+		return ""
+	}
+	p := waitForLinks(links, map[int64]bool{*pkg.Uuid: true}, nil)
+	switch {
+	case p.total == 0:
+		if pkg.Finished != nil && *pkg.Finished {
+			return packageFinished
+		}
+		return ""
+	case !p.done():
+		return ""
+	case p.failed > 0:
+		return packageFailed
+	default:
+		return packageFinished
+	}
+}
+
+// parseHook parses hook template, output of every action in it is quoted for shell.
+func parseHook(name, hook string) (*template.Template, error) {
+	if runtime.GOOS == "windows" {
+		return nil, errors.New("hooks are not supported on Windows, cmd.exe can't safely quote package values")
+	}
+	tpl, err := template.New(name).Funcs(hookFuncs).Option("missingkey=error").Parse(hook)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			quoteActions(t.Tree, t.Tree.Root)
+		}
+	}
+	return tpl, nil
+}
+
+// quoteActions appends quote function to pipeline of every action which prints value.
+func quoteActions(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			quoteActions(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			quote := parse.NewIdentifier("quote").SetTree(tree).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{quote}})
+		}
+	case *parse.IfNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	case *parse.RangeNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	case *parse.WithNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	}
+}
+
+func runHook(out io.Writer, tpl *template.Template, data hookData) error {
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, data); err != nil {
+		return err
+	}
+	cmd := exec.Command("sh", "-c", sb.String())
+	cmd.Env = append(os.Environ(),
+		"JD_PACKAGE_UUID="+idVal(data.Uuid),
+		"JD_PACKAGE_NAME="+strVal(data.Name),
+		"JD_PACKAGE_SAVE_TO="+strVal(data.SaveTo),
+		"JD_PACKAGE_EVENT="+data.Event,
+	)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+func cleanPackage(out io.Writer, dl jdownloader.Downloader, pkg jdownloader.FilePackage, links []jdownloader.DownloadLink) error {
+	toRemove := make([]int64, 0)
+	for _, link := range links {
+		if link.PackageUuid != nil && *link.PackageUuid == *pkg.Uuid && isLinkFinished(link) {
+			toRemove = append(toRemove, *link.Uuid)
+		}
+	}
+	if len(toRemove) == 0 {
+		return nil
+	}
+	if err := dl.Remove(toRemove, []int64{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d links of package %s cleaned\n", len(toRemove), strVal(pkg.Name))
+	return nil
+}
+
+// pruneWatchState removes entries of packages which are no longer listed, so that state does not grow forever.
+// It tells whether any entry was removed.
+func pruneWatchState(state map[string]watchStateEntry, pkgs []jdownloader.FilePackage) bool {
+	present := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Uuid != nil {
+			present[idVal(pkg.Uuid)] = true
+		}
+	}
+	pruned := false
+	for key := range state {
+		if !present[key] {
+			delete(state, key)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+func readWatchState(path string) (map[string]watchStateEntry, error) {
+	state := make(map[string]watchStateEntry)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+func writeWatchState(path string, state map[string]watchStateEntry) error {
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func shellQuote(v interface{}) shellQuoted {
+	if q, ok := v.(shellQuoted); ok {
+		return q
+	}
+	var s string
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.IsValid() && (rv.Kind() != reflect.Pointer || !rv.IsNil()) {
+		s = fmt.Sprint(rv.Interface())
+	}
+	return shellQuoted("'" + strings.ReplaceAll(s, "'", `'\''`) + "'")
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2026 Richard Kosegi
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/rkosegi/jdownloader-go/jdownloader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageEvent(t *testing.T) {
+	pkg := jdownloader.FilePackage{Uuid: pint64(10)}
+	links := []jdownloader.DownloadLink{
+		{Uuid: pint64(1), PackageUuid: pint64(10), Finished: pbool(true)},
+		{Uuid: pint64(2), PackageUuid: pint64(10)},
+	}
+	assert.Equal(t, "", packageEvent(pkg, links))
+	links[1].Finished = pbool(true)
+	assert.Equal(t, packageFinished, packageEvent(pkg, links))
+	links[1].Finished = nil
+	links[1].Skipped = pbool(true)
+	assert.Equal(t, packageFailed, packageEvent(pkg, links))
+}
+
+func TestWatchState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), watchStateFileName)
+	state, err := readWatchState(path)
+	assert.NoError(t, err)
+	assert.Empty(t, state)
+	state["10"] = watchStateEntry{Event: packageFinished, Time: time.Now()}
+	assert.NoError(t, writeWatchState(path, state))
+	state, err = readWatchState(path)
+	assert.NoError(t, err)
+	assert.Equal(t, packageFinished, state["10"].Event)
+}
+
+func TestPruneWatchState(t *testing.T) {
+	state := map[string]watchStateEntry{"10": {Event: packageFinished}, "20": {Event: packageFailed}}
+	assert.False(t, pruneWatchState(state, []jdownloader.FilePackage{{Uuid: pint64(10)}, {Uuid: pint64(20)}}))
+	assert.True(t, pruneWatchState(state, []jdownloader.FilePackage{{Uuid: pint64(20)}, {}}))
+	assert.Equal(t, map[string]watchStateEntry{"20": {Event: packageFailed}}, state)
+}
+
+func TestRunHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test requires POSIX shell")
+	}
+	var buf bytes.Buffer
+	tpl, err := parseHook("test", `echo {{quote .Name}} {{.SaveTo}}{{if .Finished}} {{.Finished}}{{end}} {{.Event}} $JD_PACKAGE_UUID`)
+	assert.NoError(t, err)
+	err = runHook(&buf, tpl, hookData{
+		FilePackage: jdownloader.FilePackage{Uuid: pint64(10), Name: pstr("it's mine"), SaveTo: pstr("$(touch x); `id`"),
+			Finished: pbool(true)},
+		Event: packageFinished,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "it's mine $(touch x); `id` true finished 10\n", buf.String())
+
+	_, err = parseHook("test", "echo {{.Name")
+	assert.Error(t, err)
+}